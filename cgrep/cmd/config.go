@@ -0,0 +1,51 @@
+/*
+Copyright © 2023 kurupeku <22340645+kurupeku@users.noreply.github.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage configuration files",
+	Long: `Manage configuration files.
+Settings are read from the following files in order, and later ones take precedence:
+  - $XDG_CONFIG_HOME/cgrep/config.toml (~/.config/cgrep/config.toml if unset)
+  - .cgreprc at the searching directory
+Command-line flags take precedence over the files.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective merged settings",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 引数やフラグの検証を終えた後のエラーでは使い方を表示しない
+		cmd.SilenceUsage = true
+		fullPath, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+
+		c, loaded, err := loadSettings(cmd, fullPath)
+		if err != nil {
+			return err
+		}
+
+		w := cmd.OutOrStdout()
+		for _, path := range loaded {
+			fmt.Fprintf(w, "# loaded: %s\n", path)
+		}
+		return c.Render(w)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
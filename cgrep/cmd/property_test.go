@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"cgrep/result"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// バイナリファイルと判定するために先頭から NUL を探すバイト数
+const binaryCheckSizeForTest = 512
+
+// ランダムに生成するファイルの行に使う単語
+var randomWords = []string{"foo", "bar", "baz", "Foo", "foo_bar", "42", "", " ", "\t", "日本語", "\r"}
+
+// 比較に使う検索パターン
+var propertyPatterns = []string{`foo`, `^bar`, `baz$`, `\d+`, `(?i)foo`, `^$`, `o{2}`, `日本`, `.`}
+
+// seed を元にランダムなディレクトリ・ファイル群を root 配下に生成する関数
+// .git ディレクトリやバイナリファイルなど、検索対象外となるファイルも含める
+func genTree(t testing.TB, root string, seed int64) {
+	t.Helper()
+
+	rnd := rand.New(rand.NewSource(seed))
+	var gen func(dir string, depth int)
+	gen = func(dir string, depth int) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range rnd.Intn(5) {
+			var b bytes.Buffer
+			for range rnd.Intn(20) {
+				for range rnd.Intn(4) {
+					b.WriteString(randomWords[rnd.Intn(len(randomWords))])
+				}
+				b.WriteByte('\n')
+			}
+			if rnd.Intn(4) == 0 {
+				b.Write([]byte{'f', 'o', 'o', 0})
+			}
+			if rnd.Intn(2) == 0 {
+				b.WriteString("foo tail without newline")
+			}
+
+			name := filepath.Join(dir, "file"+string(rune('a'+i))+".txt")
+			if err := os.WriteFile(name, b.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if depth == 0 {
+			return
+		}
+		for i := range rnd.Intn(3) {
+			gen(filepath.Join(dir, "dir"+string(rune('a'+i))), depth-1)
+		}
+		if rnd.Intn(4) == 0 {
+			gen(filepath.Join(dir, ".git"), 0)
+		}
+	}
+	gen(root, 3)
+}
+
+// 並行処理を行わずに root 配下を検索し、ExecSearch と同じ形式で結果を返す関数
+func referenceSearch(t testing.TB, root string, re *regexp.Regexp) map[string][]result.Line {
+	t.Helper()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[string][]result.Line)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(b[:min(len(b), binaryCheckSizeForTest)], 0) != -1 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(cwd, path)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(b), "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for i, line := range lines {
+			line = strings.TrimSuffix(line, "\r")
+			if re.MatchString(line) {
+				want[rel] = append(want[rel], result.Line{Text: line, No: i + 1})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return want
+}
+
+// ExecSearch で root 配下を検索した結果を返す関数
+func execSearch(t testing.TB, root, pattern string) map[string][]result.Line {
+	t.Helper()
+	defer result.Reset()
+
+	if err := ExecSearch(context.Background(), root, pattern); err != nil {
+		t.Fatal(err)
+	}
+	return result.Store.Data
+}
+
+// 終了していない goroutine が baseline 以下になるまで待つ関数
+func waitGoroutines(t testing.TB, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("goroutines leaked: %d > %d\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestExecSearchProperty(t *testing.T) {
+	for seed := range int64(20) {
+		root := t.TempDir()
+		genTree(t, root, seed)
+
+		for _, pattern := range propertyPatterns {
+			re := regexp.MustCompile(pattern)
+			assert.Equal(t, referenceSearch(t, root, re), execSearch(t, root, pattern), "seed: %d, pattern: %q", seed, pattern)
+		}
+	}
+}
+
+func TestExecSearchCancel(t *testing.T) {
+	root := t.TempDir()
+	for seed := range int64(5) {
+		genTree(t, filepath.Join(root, string(rune('a'+seed))), seed)
+	}
+	baseline := runtime.NumGoroutine()
+
+	for _, wait := range []time.Duration{0, 100 * time.Microsecond, time.Millisecond} {
+		ctx, cancel := context.WithTimeout(context.Background(), wait)
+		err := ExecSearch(ctx, root, "foo")
+		cancel()
+		// スキャン中に中断された場合はエラーが返される
+		if err != nil {
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+
+		// 中断されるまでに見つかった結果は全て正しい結果に含まれる
+		want := referenceSearch(t, root, regexp.MustCompile("foo"))
+		for file, lines := range result.Store.Data {
+			assert.Subset(t, want[file], lines, "wait: %s, file: %s", wait, file)
+		}
+		result.Reset()
+
+		waitGoroutines(t, baseline)
+	}
+}
+
+func FuzzExecSearch(f *testing.F) {
+	for i, pattern := range propertyPatterns {
+		f.Add(int64(i), pattern)
+	}
+
+	f.Fuzz(func(t *testing.T, seed int64, pattern string) {
+		if len(pattern) > 32 {
+			t.Skip()
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			t.Skip()
+		}
+
+		root := t.TempDir()
+		genTree(t, root, seed)
+		assert.Equal(t, referenceSearch(t, root, re), execSearch(t, root, pattern))
+	})
+}
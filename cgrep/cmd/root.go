@@ -4,13 +4,24 @@ Copyright © 2023 kurupeku <22340645+kurupeku@users.noreply.github.com>
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"cgrep/config"
 	"cgrep/errors"
+	"cgrep/grep"
+	"cgrep/result"
+	"cgrep/stats"
+	"cgrep/structural"
 
 	"github.com/spf13/cobra"
 )
@@ -18,91 +29,397 @@ import (
 var (
 	dir         string
 	withContent bool
+	withStats   bool
+	filesFrom   string
+	files0From  string
+	onlyMatch   bool
+	capture     string
+	format      string
+	vimgrep     bool
+	null        bool
+	excludes    []string
+	types       []string
+	color       string
+	jobs        int
+	noConfig    bool
+	gitTracked  bool
+	changedFrom string
+	rev         string
+	timeout     time.Duration
+	astQuery    string
+	lang        string
+
+	// 設定ファイルとフラグをマージした設定
+	settings = config.Default()
+	// 検索用の正規表現
+	re *regexp.Regexp
+	// --only-matching, --capture, --format から生成した出力用のテンプレート
+	tmpl *result.Template
+
+	// 検索対象として明示的に指定されたファイル・ディレクトリ
+	paths []string
+	// "-" が指定された場合に読み込む標準入力
+	stdin io.Reader = os.Stdin
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "cgrep [flags] [args]",
+	Use:   "cgrep [flags] PATTERN [PATH...]",
 	Short: "Search for file names containing a argument",
 	Long: `Search file names contains argument.
 Arguments are treated as regular expressions.
 
 Args:
-  A search string that can be compiled as a regular expression`,
-	Args: cobra.ExactArgs(1),
+  PATTERN: A search string that can be compiled as a regular expression (omitted with --ast)
+  PATH:    Files or directories to search instead of --dir ("-" means stdin)`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if astQuery != "" {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// 引数やフラグの検証を終えた後のエラーでは使い方を表示しない
+		cmd.SilenceUsage = true
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 		defer cancel()
+		if timeout > 0 {
+			var cancelTimeout context.CancelFunc
+			ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+			defer cancelTimeout()
+		}
 
 		fullPath, err := filepath.Abs(dir)
 		if err != nil {
 			return err
 		}
 
-		if err := ExecSearch(ctx, fullPath, args[0]); err != nil {
+		pattern, pathArgs := "", args
+		if astQuery == "" {
+			pattern, pathArgs = args[0], args[1:]
+		}
+
+		paths, err = inputPaths(pathArgs)
+		if err != nil {
 			return err
 		}
 
-		if err := errors.Error(); err != nil {
+		settings, _, err = loadSettings(cmd, fullPath)
+		if err != nil {
 			return err
 		}
 
-		if ctx.Err() != nil {
-			return nil
+		re, err = compilePattern(pattern)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err = newTemplate(re)
+		if err != nil {
+			return err
+		}
+
+		err = ExecSearch(ctx, fullPath, pattern)
+		if ie, ok := err.(*grep.InterruptedError); ok {
+			// 中断された場合もそれまでに見つかった結果は出力する
+			Render(os.Stdout)
+			if withStats {
+				stats.Render(os.Stdout)
+			}
+			return newPartialError(ie.Err)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := errors.Error(); err != nil {
+			return err
 		}
 
 		Render(os.Stdout)
+		if withStats {
+			stats.Render(os.Stdout)
+		}
 		return nil
 	},
 }
 
+// 検索が中断され、結果が一部のみであることを表すエラー
+type partialError struct {
+	reason string
+}
+
+// 中断された原因（ctx.Err() の値）に応じた partialError を生成するファクトリ関数
+func newPartialError(cause error) *partialError {
+	if cause == context.DeadlineExceeded {
+		return &partialError{reason: fmt.Sprintf("search timed out after %s", timeout)}
+	}
+	return &partialError{reason: "search interrupted"}
+}
+
+func (e *partialError) Error() string {
+	return "partial results: " + e.reason
+}
+
 // 検索処理を非同期で実行する関数
+// ctx のキャンセルにより検索を打ち切った場合は *grep.InterruptedError を返す
 func ExecSearch(ctx context.Context, fullPath, regexpWord string) error {
-	// TODO: Implement here
-	// 以下の処理を実装する必要があります：
-	// 1. 検索文字列のバリデーション
-	//    - 引数として渡された文字列を正規表現としてコンパイルする
-	//    - 不正な正規表現の場合はエラーを返す
-	//
-	// 2. 非同期検索の準備
-	//    - 複数の検索処理を並行して実行できるよう、同期の仕組みを用意する
-	//    - 全ての検索処理の完了を待ち合わせられるようにする
-	//
-	// 3. 検索の実行
-	//    - 指定されたディレクトリに対して検索処理を開始する
-	//    - 検索処理は非同期で実行し、完了を待ち合わせる
-	//    - ctx がキャンセルされた場合に非同期処理もキャンセル可能な形で実装する
-	// ヒント：
-	// - 非同期処理は終了を待たないと期待通りに動作しません
-	// - キャンセル処理には context.Context を使用します
-	//   - context.Context は非常によく使われるので、これを期に理解を深めましょう
-	// - 正規表現のコンパイルには regexp パッケージを使用します
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	opts := grep.Options{
+		Root:     fullPath,
+		Paths:    paths,
+		Stdin:    stdin,
+		Pattern:  regexpWord,
+		BaseDir:  currentDir,
+		Excludes: settings.Exclude,
+		Types:    settings.Type,
+		Jobs:     settings.Jobs,
+		Stats:    stats.Store,
+		AST:      astQuery,
+		Lang:     lang,
+
+		GitTracked:   gitTracked,
+		ChangedSince: changedFrom,
+		Rev:          rev,
+	}
+	for m, err := range grep.Search(ctx, opts) {
+		if err != nil {
+			if fe, ok := err.(*grep.FileError); ok {
+				errors.Set(fe.Err)
+				continue
+			}
+			return err
+		}
+
+		result.SetSpans(m.Path, m.Text, m.No, m.Spans)
+	}
+
 	return nil
 }
 
 // 検索結果を出力する関数
 func Render(w io.Writer) {
-	// TODO: Implement here
-	// 以下の処理を実装する必要があります：
-	// 1. フラグに基づく出力内容の決定
-	//    - フラグの状態を確認し、ユーザーが要求した出力形式を判断する
-	//
-	// 2. 適切な形式での出力
-	//    - マッチしたファイルの一覧のみを表示するか
-	//    - マッチした行の内容も含めて表示するか
-	//
-	// ヒント：
-	// - フラグがどこに格納されているかは CLI ライブラリのドキュメントを参照しましょう
-	//   - https://github.com/spf13/cobra
+	switch {
+	case vimgrep:
+		result.RenderVimgrep(w, re, null)
+		return
+	case null:
+		result.RenderFilesNull(w)
+		return
+	case format != "":
+		result.RenderTemplate(w, tmpl)
+		return
+	case onlyMatch || capture != "":
+		result.RenderCaptures(w, tmpl)
+		return
+	}
+
+	colored := useColor(w)
+	if withContent {
+		if colored {
+			result.RenderWithContentColor(w, re)
+			return
+		}
+		result.RenderWithContent(w)
+		return
+	}
+
+	if colored {
+		result.RenderFilesColor(w)
+		return
+	}
+	result.RenderFiles(w)
+}
+
+// 設定ファイルを読み込み、指定されたフラグで上書きした設定と読み込んだファイルのパスを返す関数
+func loadSettings(cmd *cobra.Command, root string) (*config.Config, []string, error) {
+	c, loaded := config.Default(), []string{}
+	if !noConfig {
+		var err error
+		c, loaded, err = config.Load(config.Paths(root))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("exclude") {
+		c.Exclude = excludes
+	}
+	if flags.Changed("type") {
+		c.Type = types
+	}
+	if flags.Changed("color") {
+		c.Color = color
+	}
+	if flags.Changed("jobs") {
+		c.Jobs = jobs
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, loaded, nil
+}
+
+// 出力先が端末かどうかと設定に応じて、色付きで出力するかを判定する関数
+func useColor(w io.Writer) bool {
+	switch settings.Color {
+	case config.ColorAlways:
+		return true
+	case config.ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// 検索パターンをコンパイルする関数
+// 構造検索の場合はクエリを検証して空の正規表現を返す（一致箇所は検索結果の要素の位置から求める）
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if astQuery == "" {
+		return regexp.Compile(pattern)
+	}
+
+	if _, err := structural.Parse(lang, astQuery); err != nil {
+		return nil, err
+	}
+	return regexp.MustCompile(""), nil
+}
+
+// フラグに応じて一致箇所の出力用テンプレートを生成する関数
+func newTemplate(re *regexp.Regexp) (*result.Template, error) {
+	if format == "" && !onlyMatch && capture == "" {
+		return nil, nil
+	}
+
+	if format != "" {
+		return result.ParseTemplate(format, re)
+	}
+
+	if capture == "" {
+		return result.CaptureTemplate(re, "0")
+	}
+	return result.CaptureTemplate(re, capture)
 }
 
+// 引数と --files-from, --files0-from で指定された検索対象をまとめて返す関数
+func inputPaths(args []string) ([]string, error) {
+	list := append([]string{}, args...)
+	usedStdin := slices.Contains(args, "-")
+
+	for _, from := range []struct {
+		path string
+		sep  byte
+	}{
+		{path: filesFrom, sep: '\n'},
+		{path: files0From, sep: 0},
+	} {
+		if from.path == "" {
+			continue
+		}
+
+		if from.path == "-" {
+			if usedStdin {
+				return nil, fmt.Errorf("stdin can be used only once")
+			}
+			usedStdin = true
+		}
+
+		ps, err := readFileList(from.path, from.sep)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ps...)
+	}
+
+	return list, nil
+}
+
+// path のファイル（"-" の場合は標準入力）から sep 区切りのファイルパス一覧を読み込む関数
+func readFileList(path string, sep byte) ([]string, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0)
+	for _, p := range bytes.Split(b, []byte{sep}) {
+		s := string(p)
+		if sep == '\n' {
+			s = strings.TrimSuffix(s, "\r")
+		}
+		if s == "" {
+			continue
+		}
+		list = append(list, s)
+	}
+
+	return list, nil
+}
+
+// 検索が中断された場合の終了コード
+const exitPartial = 3
+
 func Execute() {
 	err := rootCmd.Execute()
+	if _, ok := err.(*partialError); ok {
+		os.Exit(exitPartial)
+	}
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&dir, "dir", "d", "./", "searching directory")
+	rootCmd.PersistentFlags().StringVarP(&dir, "dir", "d", "./", "searching directory")
+	rootCmd.PersistentFlags().BoolVar(&noConfig, "no-config", false, "ignore configuration files")
 	rootCmd.Flags().BoolVarP(&withContent, "with-content", "c", false, "render with matched content lines")
+	rootCmd.Flags().BoolVar(&withStats, "stats", false, "render search statistics after the results")
+	rootCmd.Flags().BoolVarP(&onlyMatch, "only-matching", "o", false, "render only the matched parts of the lines")
+	rootCmd.Flags().StringVar(&capture, "capture", "", "render only the given capture group (number or name)")
+	rootCmd.Flags().StringVar(&format, "format", "", "render each match with a template (e.g. '{path}:{line}:{1}')")
+	rootCmd.Flags().BoolVar(&vimgrep, "vimgrep", false, "render each match as 'path:line:column:text'")
+	rootCmd.Flags().BoolVar(&null, "null", false, "separate file names with NUL instead of newline or ':'")
+	// グループにまとめると with-content と only-matching なども排他になるため、1 組ずつ指定する
+	for _, f := range []string{"vimgrep", "null"} {
+		for _, other := range []string{"with-content", "only-matching", "capture", "format"} {
+			rootCmd.MarkFlagsMutuallyExclusive(f, other)
+		}
+	}
+	rootCmd.PersistentFlags().StringArrayVar(&excludes, "exclude", []string{}, "skip files and directories whose names match the glob pattern")
+	rootCmd.PersistentFlags().StringArrayVarP(&types, "type", "t", []string{}, "search only files of the given type (e.g. go, markdown)")
+	rootCmd.PersistentFlags().StringVar(&color, "color", config.ColorAuto, "colorize the output (auto, always, never)")
+	rootCmd.PersistentFlags().IntVarP(&jobs, "jobs", "j", 0, "maximum number of files searched at the same time (0 means unlimited)")
+	rootCmd.Flags().BoolVar(&gitTracked, "git-tracked", false, "search only files tracked by git")
+	rootCmd.Flags().StringVar(&changedFrom, "changed-since", "", "search only files changed since the git revision")
+	rootCmd.Flags().StringVar(&rev, "rev", "", "search file contents at the git revision instead of the working tree")
+	rootCmd.MarkFlagsMutuallyExclusive("git-tracked", "changed-since")
+	rootCmd.Flags().DurationVar(&timeout, "timeout", 0, "stop searching after the duration and render partial results (e.g. 5s)")
+	rootCmd.Flags().StringVar(&astQuery, "ast", "", "search syntax nodes instead of PATTERN (e.g. 'call:context.WithTimeout')")
+	rootCmd.Flags().StringVar(&lang, "lang", "go", "language of the source code searched with --ast")
+	rootCmd.MarkFlagsMutuallyExclusive("ast", "type")
+	rootCmd.Flags().StringVar(&filesFrom, "files-from", "", "read newline-separated paths to search from a file (\"-\" means stdin)")
+	rootCmd.Flags().StringVar(&files0From, "files0-from", "", "read NUL-separated paths to search from a file (\"-\" means stdin)")
 }
@@ -6,11 +6,17 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"cgrep/grep"
 	"cgrep/result"
+	"cgrep/stats"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -117,3 +123,132 @@ func TestRender(t *testing.T) {
 		})
 	}
 }
+
+func TestExecSearchStats(t *testing.T) {
+	stats.Reset()
+	defer stats.Reset()
+	defer result.Reset()
+
+	assert.NoError(t, ExecSearch(context.Background(), testDirPath, `_1\-\d`))
+	assert.Equal(t, 2, stats.Store.Dirs)
+	assert.Equal(t, 2, stats.Store.Files)
+	assert.Equal(t, int64(82), stats.Store.Bytes)
+	assert.Equal(t, 1, stats.Store.MatchedFiles)
+	assert.Equal(t, 3, stats.Store.MatchedLines)
+	assert.NotZero(t, stats.Store.ScanTime)
+	assert.NotZero(t, stats.Store.GrepTime)
+}
+
+func TestInputPaths(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		filesFrom  string
+		files0From string
+		stdin      string
+		want       []string
+		assertion  assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "Args only",
+			args:      []string{"a.txt", "-"},
+			want:      []string{"a.txt", "-"},
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Newline-separated list from stdin",
+			args:      []string{"a.txt"},
+			filesFrom: "-",
+			stdin:     "b.txt\r\n\nc d.txt\n",
+			want:      []string{"a.txt", "b.txt", "c d.txt"},
+			assertion: assert.NoError,
+		},
+		{
+			name:       "NUL-separated list from stdin",
+			files0From: "-",
+			stdin:      "b.txt\x00c\nd.txt\x00",
+			want:       []string{"b.txt", "c\nd.txt"},
+			assertion:  assert.NoError,
+		},
+		{
+			name:      "Stdin used twice",
+			args:      []string{"-"},
+			filesFrom: "-",
+			want:      nil,
+			assertion: assert.Error,
+		},
+		{
+			name:      "List file not found",
+			filesFrom: "../testdata/not_found",
+			want:      nil,
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				filesFrom, files0From, stdin = "", "", os.Stdin
+			}()
+
+			filesFrom, files0From = tt.filesFrom, tt.files0From
+			stdin = strings.NewReader(tt.stdin)
+			got, err := inputPaths(tt.args)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecSearchPaths(t *testing.T) {
+	defer result.Reset()
+	defer func() {
+		paths, stdin = nil, os.Stdin
+	}()
+
+	paths = []string{"../testdata/dir/text.txt", "-"}
+	stdin = strings.NewReader("no match\nsample_text_2-9\n")
+	assert.NoError(t, ExecSearch(context.Background(), testDirPath, `_2\-[19]`))
+	assert.Equal(t, map[string][]result.Line{
+		"../testdata/dir/text.txt": {{Text: "sample_text_2-1", No: 1}},
+		"(standard input)":         {{Text: "sample_text_2-9", No: 2}},
+	}, result.Store.Data)
+}
+
+func TestExecSearchMissingPaths(t *testing.T) {
+	defer result.Reset()
+	defer stats.Reset()
+	defer func() {
+		paths = nil
+	}()
+
+	// 削除されたファイルが含まれていても、残りのファイルは検索する
+	paths = []string{"../testdata/deleted.txt", "../testdata/dir/text.txt"}
+	assert.NoError(t, ExecSearch(context.Background(), testDirPath, `_2\-1`))
+	assert.Equal(t, map[string][]result.Line{
+		"../testdata/dir/text.txt": {{Text: "sample_text_2-1", No: 1}},
+	}, result.Store.Data)
+	assert.Equal(t, map[string]int{stats.ReasonNotFound: 1}, stats.Store.Skipped)
+}
+
+func TestExecSearchTimeout(t *testing.T) {
+	defer result.Reset()
+	defer func() {
+		paths, stdin, timeout = nil, os.Stdin, 0
+	}()
+
+	// 書き込まれることのない標準入力を読み込んでいても、タイムアウトで検索が終了することを確認する
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	paths = []string{"../testdata/dir/text.txt", "-"}
+	stdin = pr
+	timeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := ExecSearch(ctx, testDirPath, `_2\-1`)
+	assert.Equal(t, &grep.InterruptedError{Err: context.DeadlineExceeded}, err)
+	assert.Equal(t, map[string][]result.Line{
+		"../testdata/dir/text.txt": {{Text: "sample_text_2-1", No: 1}},
+	}, result.Store.Data)
+	assert.EqualError(t, newPartialError(context.DeadlineExceeded), "partial results: search timed out after 100ms")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2023 kurupeku <22340645+kurupeku@users.noreply.github.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"cgrep/server"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	addr     string
+	root     string
+	interval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve searches over HTTP",
+	Long: `Serve searches over HTTP.
+The file tree under --root is scanned once at startup and kept in memory.
+
+Endpoints:
+  GET /search?q=PATTERN[&include=GLOB...]
+    Stream matched lines as JSON Lines ({"path":...,"line":...,"text":...}).
+    The search is cancelled when the client disconnects.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 引数やフラグの検証を終えた後のエラーでは使い方を表示しない
+		cmd.SilenceUsage = true
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		fullPath, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+
+		settings, _, err = loadSettings(cmd, fullPath)
+		if err != nil {
+			return err
+		}
+
+		s, err := server.New(ctx, server.Options{
+			Root:     fullPath,
+			Excludes: settings.Exclude,
+			Types:    settings.Type,
+			Jobs:     settings.Jobs,
+		})
+		if err != nil {
+			return err
+		}
+		if interval > 0 {
+			go s.Refresh(ctx, interval)
+		}
+
+		srv := &http.Server{Addr: addr, Handler: s.Handler()}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "serving %s on %s\n", fullPath, addr)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen on")
+	serveCmd.Flags().StringVar(&root, "root", "./", "directory to serve searches for")
+	serveCmd.Flags().DurationVar(&interval, "refresh", time.Minute, "interval to rescan the file tree (0 disables rescanning)")
+	// ルートコマンドの --dir は --root の別名として受け付け、ヘルプには表示しない
+	serveCmd.Flags().StringVarP(&root, "dir", "d", "./", "alias of --root")
+	serveCmd.Flags().MarkHidden("dir")
+	rootCmd.AddCommand(serveCmd)
+}
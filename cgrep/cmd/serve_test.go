@@ -0,0 +1,23 @@
+/*
+Copyright © 2023 kurupeku <22340645+kurupeku@users.noreply.github.com>
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeFlags(t *testing.T) {
+	// --jobs はルートコマンドのフラグをそのまま使う
+	assert.Nil(t, serveCmd.LocalFlags().Lookup("jobs"))
+	assert.NotNil(t, serveCmd.InheritedFlags().Lookup("jobs"))
+
+	// --dir は --root の別名としてヘルプに表示しない
+	assert.Nil(t, serveCmd.InheritedFlags().Lookup("dir"))
+	d := serveCmd.Flags().Lookup("dir")
+	if assert.NotNil(t, d) {
+		assert.True(t, d.Hidden)
+	}
+}
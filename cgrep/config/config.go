@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// 検索ルートに置くリポジトリごとの設定ファイル名
+	RepoFileName = ".cgreprc"
+
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+type Config struct {
+	Exclude []string `toml:"exclude"`
+	Type    []string `toml:"type"`
+	Color   string   `toml:"color"`
+	Jobs    int      `toml:"jobs"`
+}
+
+// 設定ファイルが存在しない場合の設定を返す関数
+func Default() *Config {
+	return &Config{
+		Exclude: []string{},
+		Type:    []string{},
+		Color:   ColorAuto,
+		Jobs:    0,
+	}
+}
+
+// 読み込む設定ファイルのパスを優先度の低い順に返す関数
+// ユーザーごとの設定ファイルは $XDG_CONFIG_HOME/cgrep/config.toml（未設定の場合は ~/.config/cgrep/config.toml）
+func Paths(root string) []string {
+	paths := make([]string, 0, 2)
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configDir = filepath.Join(home, ".config")
+		}
+	}
+	if configDir != "" {
+		paths = append(paths, filepath.Join(configDir, "cgrep", "config.toml"))
+	}
+
+	return append(paths, filepath.Join(root, RepoFileName))
+}
+
+// 設定ファイルを優先度の低い順に読み込んでマージした設定と、実際に読み込んだファイルのパスを返す関数
+// 存在しないファイルは無視する
+func Load(paths []string) (*Config, []string, error) {
+	c := Default()
+	loaded := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ok, err := c.merge(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			loaded = append(loaded, path)
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, loaded, nil
+}
+
+// path の設定ファイルに記述されている項目のみで設定を上書きするメソッド
+func (c *Config) merge(path string) (bool, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var fc Config
+	md, err := toml.Decode(string(b), &fc)
+	if err != nil {
+		return false, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return false, fmt.Errorf("%s: unknown key '%s'", path, undecoded[0].String())
+	}
+
+	if md.IsDefined("exclude") {
+		c.Exclude = fc.Exclude
+	}
+	if md.IsDefined("type") {
+		c.Type = fc.Type
+	}
+	if md.IsDefined("color") {
+		c.Color = fc.Color
+	}
+	if md.IsDefined("jobs") {
+		c.Jobs = fc.Jobs
+	}
+	return true, nil
+}
+
+// 設定内容が正しいかを検証するメソッド
+func (c *Config) Validate() error {
+	switch c.Color {
+	case ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("color must be one of %s, %s, %s: %s", ColorAuto, ColorAlways, ColorNever, c.Color)
+	}
+
+	if c.Jobs < 0 {
+		return fmt.Errorf("jobs must be 0 or more: %d", c.Jobs)
+	}
+	return nil
+}
+
+// 設定内容を TOML 形式で出力するメソッド
+func (c *Config) Render(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(c)
+}
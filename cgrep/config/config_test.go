@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+
+	assert.Equal(t, []string{"/xdg/cgrep/config.toml", "/repo/.cgreprc"}, Paths("/repo"))
+}
+
+func TestLoad(t *testing.T) {
+	user := writeConfig(t, "config.toml", `exclude = ["node_modules"]
+color = "never"
+jobs = 4
+`)
+	repo := writeConfig(t, RepoFileName, `type = ["go"]
+jobs = 2
+`)
+	notFound := filepath.Join(t.TempDir(), "not_found.toml")
+
+	tests := []struct {
+		name       string
+		paths      []string
+		want       *Config
+		wantLoaded []string
+		assertion  assert.ErrorAssertionFunc
+	}{
+		{
+			name:       "No files",
+			paths:      []string{notFound},
+			want:       Default(),
+			wantLoaded: []string{},
+			assertion:  assert.NoError,
+		},
+		{
+			name:  "Later files take precedence",
+			paths: []string{user, notFound, repo},
+			want: &Config{
+				Exclude: []string{"node_modules"},
+				Type:    []string{"go"},
+				Color:   ColorNever,
+				Jobs:    2,
+			},
+			wantLoaded: []string{user, repo},
+			assertion:  assert.NoError,
+		},
+		{
+			name:      "Unknown key",
+			paths:     []string{writeConfig(t, "config.toml", "colour = \"never\"\n")},
+			assertion: assert.Error,
+		},
+		{
+			name:      "Invalid color",
+			paths:     []string{writeConfig(t, "config.toml", "color = \"rainbow\"\n")},
+			assertion: assert.Error,
+		},
+		{
+			name:      "Invalid toml",
+			paths:     []string{writeConfig(t, "config.toml", "jobs = \n")},
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, loaded, err := Load(tt.paths)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantLoaded, loaded)
+		})
+	}
+}
+
+func TestConfig_Render(t *testing.T) {
+	c := &Config{Exclude: []string{"vendor"}, Type: []string{}, Color: ColorAuto, Jobs: 8}
+
+	buf := bytes.NewBuffer([]byte{})
+	assert.NoError(t, c.Render(buf))
+	assert.Equal(t, "exclude = [\"vendor\"]\ntype = []\ncolor = \"auto\"\njobs = 8\n", buf.String())
+}
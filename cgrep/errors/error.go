@@ -16,6 +16,9 @@ var Store = &ErrorLogs{}
 
 // Store に保存されたエラーを error として返す関数
 func Error() error {
+	Store.Lock()
+	defer Store.Unlock()
+
 	if hasError() {
 		ss := make([]string, 1, len(Store.errs)+1)
 		ss[0] = "[Error]"
@@ -31,6 +34,9 @@ func Error() error {
 
 // error を渡すとグローバル変数上に保存する関数
 func Set(err error) {
+	Store.Lock()
+	defer Store.Unlock()
+
 	Store.errs = append(Store.errs, err)
 }
 
@@ -0,0 +1,170 @@
+// git コマンドを利用して検索対象のファイルやその内容を取得するパッケージ
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// リビジョン上のファイル
+type Blob struct {
+	// dir からの相対パス
+	Path string
+	// オブジェクト名
+	Object string
+}
+
+// dir 配下でインデックスに登録されているファイルを、dir からの相対パスで返す関数
+func TrackedFiles(ctx context.Context, dir string) ([]string, error) {
+	out, err := run(ctx, dir, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	return split(out), nil
+}
+
+// dir 配下で ref から変更されたファイルを、dir からの相対パスで返す関数
+// rev が空の場合は作業ツリーと比較し、未追跡のファイルも変更されたファイルとして扱う
+// 削除されたファイルは含まない
+func ChangedFiles(ctx context.Context, dir, ref, rev string) ([]string, error) {
+	args := []string{"diff", "--name-only", "-z", "--relative", "--diff-filter=d", ref}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	out, err := run(ctx, dir, append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	files := split(out)
+	if rev != "" {
+		return files, nil
+	}
+
+	out, err = run(ctx, dir, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	return append(files, split(out)...), nil
+}
+
+// dir 配下でリビジョン rev に含まれる通常のファイルを返す関数
+// シンボリックリンクやサブモジュールは含まない
+func RevFiles(ctx context.Context, dir, rev string) ([]Blob, error) {
+	out, err := run(ctx, dir, "ls-tree", "-r", "-z", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make([]Blob, 0)
+	for _, entry := range split(out) {
+		// <mode> SP <type> SP <object> TAB <file>
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			return nil, fmt.Errorf("unexpected ls-tree output: %s", entry)
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected ls-tree output: %s", entry)
+		}
+		if fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+
+		blobs = append(blobs, Blob{Path: path, Object: fields[2]})
+	}
+	return blobs, nil
+}
+
+// git cat-file --batch を使ってオブジェクトの内容を順に読み込むためのリーダー
+type BlobReader struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+// dir のリポジトリからオブジェクトを読み込むためのリーダーを生成するファクトリ関数
+func NewBlobReader(ctx context.Context, dir string) (*BlobReader, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "cat-file", "--batch")
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &BlobReader{cmd: cmd, in: in, out: bufio.NewReader(out)}, nil
+}
+
+// オブジェクト名を渡すとその内容を返すメソッド
+func (b *BlobReader) Read(object string) ([]byte, error) {
+	if _, err := fmt.Fprintln(b.in, object); err != nil {
+		return nil, err
+	}
+
+	// <object> SP <type> SP <size> LF <contents> LF
+	header, err := b.out.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected cat-file output: %s", strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	content := make([]byte, size+1)
+	if _, err := io.ReadFull(b.out, content); err != nil {
+		return nil, err
+	}
+	return content[:size], nil
+}
+
+// git cat-file を終了させるメソッド
+func (b *BlobReader) Close() error {
+	if err := b.in.Close(); err != nil {
+		return err
+	}
+	return b.cmd.Wait()
+}
+
+// dir で git コマンドを実行し、標準出力の内容を返す関数
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// NUL 区切りの出力を分割する関数
+func split(out []byte) []string {
+	ss := make([]string, 0)
+	for _, s := range strings.Split(string(out), "\x00") {
+		if s != "" {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// テスト用のリポジトリを作成し、そのパスを返す関数
+// 1 つ目のコミットで a.txt と dir/b.txt を、2 つ目のコミットで a.txt を変更して c.txt を追加する
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	repo := t.TempDir()
+	gitCmd := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitCmd("init", "-q")
+	write("a.txt", "first\n")
+	write("dir/b.txt", "second\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "first")
+	gitCmd("tag", "v1")
+
+	write("a.txt", "first updated\n")
+	write("c.txt", "third\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "second")
+
+	return repo
+}
+
+func TestTrackedFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "untracked.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := TrackedFiles(context.Background(), repo)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "c.txt", "dir/b.txt"}, got)
+
+	got, err = TrackedFiles(context.Background(), filepath.Join(repo, "dir"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b.txt"}, got)
+}
+
+func TestChangedFiles(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "untracked.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(repo, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		ref       string
+		rev       string
+		want      []string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{name: "Working tree", ref: "v1", want: []string{"a.txt", "c.txt", "untracked.txt"}, assertion: assert.NoError},
+		{name: "Between revisions", ref: "v1", rev: "HEAD", want: []string{"a.txt", "c.txt"}, assertion: assert.NoError},
+		{name: "Invalid ref", ref: "unknown", want: nil, assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ChangedFiles(context.Background(), repo, tt.ref, tt.rev)
+			tt.assertion(t, err)
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRevFilesAndBlobReader(t *testing.T) {
+	repo := newTestRepo(t)
+
+	blobs, err := RevFiles(context.Background(), repo, "v1")
+	assert.NoError(t, err)
+
+	paths := make([]string, 0, len(blobs))
+	for _, b := range blobs {
+		paths = append(paths, b.Path)
+	}
+	assert.Equal(t, []string{"a.txt", "dir/b.txt"}, paths)
+
+	br, err := NewBlobReader(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.Close()
+
+	for i, want := range []string{"first\n", "second\n"} {
+		got, err := br.Read(blobs[i].Object)
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	}
+
+	_, err = br.Read("0000000000000000000000000000000000000000")
+	assert.Error(t, err)
+}
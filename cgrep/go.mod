@@ -3,6 +3,7 @@ module cgrep
 go 1.24.0
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/spf13/cobra v1.6.1
 	github.com/stretchr/testify v1.10.0
 )
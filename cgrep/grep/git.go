@@ -0,0 +1,117 @@
+package grep
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cgrep/git"
+	"cgrep/stats"
+)
+
+// git を利用して検索対象のファイルのフルパスを返すメソッド
+func (r *chanReporter) gitFiles(j *job) ([]string, error) {
+	var (
+		files []string
+		err   error
+	)
+	if j.opts.ChangedSince != "" {
+		files, err = git.ChangedFiles(r.ctx, j.root, j.opts.ChangedSince, "")
+	} else {
+		files, err = git.TrackedFiles(r.ctx, j.root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fullPaths := make([]string, 0, len(files))
+	for _, f := range files {
+		if reason := j.cfg.SkipReason(f); reason != "" {
+			r.stats.Skip(reason)
+			continue
+		}
+
+		fullPath := filepath.Join(j.root, f)
+		fi, err := os.Lstat(fullPath)
+		if err != nil {
+			// インデックスには残っているが作業ツリーから削除されたファイル
+			r.stats.Skip(stats.ReasonNotFound)
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			r.stats.Skip(stats.ReasonNotRegular)
+			continue
+		}
+		fullPaths = append(fullPaths, fullPath)
+	}
+	return fullPaths, nil
+}
+
+// リビジョン時点のファイルの内容を検索するメソッド
+// 一致した行のファイル名は "<リビジョン>:<パス>" の形式で返す
+func (r *chanReporter) runRev(j *job) {
+	start := time.Now()
+	blobs, err := git.RevFiles(r.ctx, j.root, j.opts.Rev)
+	if err != nil {
+		r.send(item{err: err})
+		return
+	}
+
+	var changed map[string]bool
+	if j.opts.ChangedSince != "" {
+		files, err := git.ChangedFiles(r.ctx, j.root, j.opts.ChangedSince, j.opts.Rev)
+		if err != nil {
+			r.send(item{err: err})
+			return
+		}
+
+		changed = make(map[string]bool, len(files))
+		for _, f := range files {
+			changed[f] = true
+		}
+	}
+
+	targets := make([]git.Blob, 0, len(blobs))
+	for _, b := range blobs {
+		if changed != nil && !changed[b.Path] {
+			continue
+		}
+		if reason := j.cfg.SkipReason(b.Path); reason != "" {
+			r.stats.Skip(reason)
+			continue
+		}
+		targets = append(targets, b)
+	}
+	r.stats.SetScanTime(time.Since(start))
+
+	start = time.Now()
+	br, err := git.NewBlobReader(r.ctx, j.root)
+	if err != nil {
+		r.send(item{err: err})
+		return
+	}
+	defer br.Close()
+
+	for _, b := range targets {
+		if r.ctx.Err() != nil {
+			break
+		}
+
+		content, err := br.Read(b.Object)
+		if err != nil {
+			r.Error(err)
+			break
+		}
+
+		name, err := r.displayPath(filepath.Join(j.root, b.Path))
+		if err != nil {
+			r.Error(err)
+			continue
+		}
+		if err := j.grep(r.ctx, j.opts.Rev+":"+name, bytes.NewReader(content), r); err != nil {
+			r.Error(err)
+		}
+	}
+	r.stats.SetGrepTime(time.Since(start))
+}
@@ -0,0 +1,357 @@
+// cgrep の検索エンジンを CLI を介さずに利用するためのパッケージ
+package grep
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"cgrep/search"
+	"cgrep/stats"
+	"cgrep/structural"
+)
+
+const (
+	// 一致結果を受け渡すチャネルのバッファサイズ
+	channelLen = 100
+	// 標準入力を検索した場合に Match.Path として返される名前
+	StdinName = "(standard input)"
+)
+
+type Options struct {
+	// 検索ルートとするディレクトリ（相対パスの場合はカレントディレクトリ基準）
+	Root string
+	// 検索対象のファイル・ディレクトリ（空でない場合は Root の代わりにこちらを検索する）
+	// "-" を含む場合は Stdin を検索する
+	Paths []string
+	// Paths に "-" が含まれる場合に読み込む入力
+	Stdin io.Reader
+	// 検索用の正規表現
+	Pattern string
+	// 空でない場合、Pattern の代わりにこの構造検索のクエリ（"call:context.WithTimeout" など）で検索する
+	// 検索対象は Lang のファイルに限られ、Types は無視される
+	AST string
+	// 構造検索の対象とする言語（空の場合は "go"）
+	Lang string
+	// 空でない場合、Match.Path をこのディレクトリからの相対パスにする
+	BaseDir string
+	// ファイル名・ディレクトリ名がいずれかのパターンに一致する場合は検索対象から除外する
+	Excludes []string
+	// 空でない場合、いずれかの種類（search.Types を参照）に該当するファイルのみを検索する
+	Types []string
+	// 同時に内容を検索するファイル数の上限（0 の場合は無制限）
+	Jobs int
+	// Root 配下でインデックスに登録されているファイルのみを検索する
+	GitTracked bool
+	// 空でない場合、Root 配下でこのリビジョンから変更されたファイルのみを検索する
+	ChangedSince string
+	// 空でない場合、作業ツリーの代わりにこのリビジョン時点のファイルの内容を検索する
+	Rev string
+	// 統計情報の集計先（nil の場合は集計結果を破棄する）
+	Stats *stats.Stats
+}
+
+type Match struct {
+	Path string
+	Text string
+	No   int
+	// 構造検索の場合、行内の一致箇所のバイト単位の範囲 [開始, 終了)（正規表現で検索した場合は nil）
+	Spans [][2]int
+}
+
+// 個々のディレクトリ・ファイルの検索中に発生したエラー
+// このエラーが返された後も検索は継続される
+type FileError struct {
+	Err error
+}
+
+func (e *FileError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ctx のキャンセルやタイムアウトにより、全ての検索を終える前に打ち切られたことを表すエラー
+// Search はこのエラーを最後に返して終了し、それまでに返した一致行は有効な結果として扱える
+type InterruptedError struct {
+	// ctx.Err() の値
+	Err error
+}
+
+func (e *InterruptedError) Error() string {
+	return "search interrupted: " + e.Err.Error()
+}
+
+func (e *InterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// opts に従って非同期で検索を実行し、一致した行を見つかった順に返す関数
+// 返される順序は実行ごとに異なる
+// 正規表現のコンパイルや検索ルートのスキャンに失敗した場合は、そのエラーを返して終了する
+// ctx がキャンセルされ検索を打ち切った場合は、最後に *InterruptedError を返す
+// ループを途中で抜けた場合は検索をキャンセルし、全ての goroutine の終了を待ってから戻る
+func Search(ctx context.Context, opts Options) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		var (
+			re  *regexp.Regexp
+			q   *structural.Query
+			err error
+		)
+		if opts.AST != "" {
+			q, err = structural.Parse(opts.lang(), opts.AST)
+		} else {
+			re, err = regexp.Compile(opts.Pattern)
+		}
+		if err != nil {
+			yield(Match{}, err)
+			return
+		}
+
+		root, err := filepath.Abs(opts.Root)
+		if err != nil {
+			yield(Match{}, err)
+			return
+		}
+
+		var (
+			paths    []string
+			useStdin bool
+		)
+		for _, p := range opts.Paths {
+			if p == "-" {
+				useStdin = true
+				continue
+			}
+
+			fullPath, err := filepath.Abs(p)
+			if err != nil {
+				yield(Match{}, err)
+				return
+			}
+			paths = append(paths, fullPath)
+		}
+		if useStdin && opts.Stdin == nil {
+			yield(Match{}, errors.New("stdin is not set"))
+			return
+		}
+		if opts.useGit() && len(opts.Paths) > 0 {
+			yield(Match{}, errors.New("paths cannot be used with git scopes"))
+			return
+		}
+
+		st := opts.Stats
+		if st == nil {
+			st = stats.New()
+		}
+
+		cfg := &search.Config{Excludes: opts.Excludes, Types: opts.Types, Jobs: opts.Jobs}
+		if q != nil {
+			cfg.Types = []string{q.Type()}
+			cfg.Grep = q.Grep
+		}
+		if err := cfg.Validate(); err != nil {
+			yield(Match{}, err)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		r := &chanReporter{ctx: ctx, ch: make(chan item, channelLen), baseDir: opts.BaseDir, stats: st}
+		cfg.Reporter = r
+		go r.run(&job{opts: opts, root: root, paths: paths, useStdin: useStdin, re: re, cfg: cfg})
+
+		for it := range r.ch {
+			if !yield(it.match, it.err) {
+				cancel()
+				for range r.ch {
+				}
+				return
+			}
+		}
+	}
+}
+
+// 検索 1 回分の実行内容
+type job struct {
+	opts     Options
+	root     string
+	paths    []string
+	useStdin bool
+	re       *regexp.Regexp
+	cfg      *search.Config
+}
+
+// ファイル以外の内容を検索するメソッド
+func (j *job) grep(ctx context.Context, name string, src io.Reader, r search.Reporter) error {
+	if j.cfg.Grep != nil {
+		return j.cfg.Grep(ctx, name, src, r)
+	}
+	return search.GrepReader(ctx, name, src, j.re, r)
+}
+
+// 構造検索の対象とする言語を返すメソッド
+func (o Options) lang() string {
+	if o.Lang == "" {
+		return "go"
+	}
+	return o.Lang
+}
+
+// git を利用して検索対象を決めるかを返すメソッド
+func (o Options) useGit() bool {
+	return o.GitTracked || o.ChangedSince != "" || o.Rev != ""
+}
+
+type item struct {
+	match Match
+	err   error
+}
+
+// 検索結果をチャネルへ送信する search.Reporter
+type chanReporter struct {
+	ctx     context.Context
+	ch      chan item
+	baseDir string
+	stats   *stats.Stats
+}
+
+// 検索対象のスキャンと検索を行い、全ての検索が終了したらチャネルを閉じるメソッド
+func (r *chanReporter) run(j *job) {
+	defer close(r.ch)
+
+	if j.opts.Rev != "" {
+		r.runRev(j)
+	} else {
+		r.runFiles(j)
+	}
+
+	// 検索を終える前にキャンセルされた場合は、結果が一部のみであることを伝える
+	// 受信側はループを抜けた場合もチャネルを読み切るため、キャンセル後も送信できる
+	if err := r.ctx.Err(); err != nil {
+		r.ch <- item{err: &InterruptedError{Err: err}}
+	}
+}
+
+// 作業ツリーのファイルをスキャンして検索するメソッド
+func (r *chanReporter) runFiles(j *job) {
+	var (
+		wg    = new(sync.WaitGroup)
+		start = time.Now()
+		d     search.Dir
+		err   error
+	)
+	switch {
+	case j.opts.useGit():
+		var files []string
+		files, err = r.gitFiles(j)
+		if err == nil {
+			d, err = search.NewFiles(r.ctx, wg, files, j.re, j.cfg)
+		}
+	case len(j.paths) > 0 || j.useStdin:
+		d, err = search.NewFiles(r.ctx, wg, j.paths, j.re, j.cfg)
+	default:
+		d, err = search.NewWithConfig(r.ctx, wg, j.root, j.re, j.cfg)
+	}
+	if err != nil {
+		r.send(item{err: err})
+		return
+	}
+	r.stats.SetScanTime(time.Since(start))
+
+	start = time.Now()
+	wg.Add(1)
+	go d.Search(r.ctx)
+	if j.useStdin {
+		stdin := &ctxReader{ctx: r.ctx, r: j.opts.Stdin}
+		if err := j.grep(r.ctx, StdinName, stdin, r); err != nil {
+			r.Error(err)
+		}
+	}
+	wg.Wait()
+	r.stats.SetGrepTime(time.Since(start))
+}
+
+func (r *chanReporter) Match(fullPath, txt string, no int) {
+	r.MatchSpans(fullPath, txt, no, nil)
+}
+
+func (r *chanReporter) MatchSpans(fullPath, txt string, no int, spans [][2]int) {
+	path, err := r.displayPath(fullPath)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	r.send(item{match: Match{Path: path, Text: txt, No: no, Spans: spans}})
+}
+
+// baseDir が指定されている場合はファイルのフルパスを baseDir からの相対パスに変換するメソッド
+func (r *chanReporter) displayPath(fullPath string) (string, error) {
+	if r.baseDir == "" || !filepath.IsAbs(fullPath) {
+		return fullPath, nil
+	}
+
+	return filepath.Rel(r.baseDir, fullPath)
+}
+
+func (r *chanReporter) Error(err error) {
+	r.send(item{err: &FileError{Err: err}})
+}
+
+func (r *chanReporter) Stats() *stats.Stats {
+	return r.stats
+}
+
+// キャンセルされるまでチャネルへの送信を試みるメソッド
+// キャンセル後の一致行やエラーは中断に伴うものとして破棄する
+func (r *chanReporter) send(it item) {
+	if r.ctx.Err() != nil {
+		return
+	}
+	select {
+	case <-r.ctx.Done():
+	case r.ch <- it:
+	}
+}
+
+// ctx がキャンセルされた場合は読み込みの完了を待たずに戻る io.Reader
+// 端末やパイプからの読み込みはキャンセルできないため、標準入力を読み込む際に利用する
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, len(p))
+	done := make(chan readResult, 1)
+	go func() {
+		n, err := c.r.Read(buf)
+		done <- readResult{n: n, err: err}
+	}()
+
+	select {
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	case res := <-done:
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	}
+}
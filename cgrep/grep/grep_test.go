@@ -0,0 +1,118 @@
+package grep
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"cgrep/stats"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testDirPath, _ = filepath.Abs("../testdata")
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		want      []Match
+		assertion assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Matched",
+			opts: Options{Root: testDirPath, Pattern: `_1\-\d`, BaseDir: testDirPath},
+			want: []Match{
+				{Path: "text.txt", Text: "sample_text_1-1", No: 1},
+				{Path: "text.txt", Text: "  sample_text_1-2", No: 2},
+				{Path: "text.txt", Text: "sample_text_1-3", No: 3},
+			},
+			assertion: assert.NoError,
+		},
+		{
+			name: "Matched with full path",
+			opts: Options{Root: "../testdata/dir", Pattern: `_2\-1`},
+			want: []Match{
+				{Path: filepath.Join(testDirPath, "dir", "text.txt"), Text: "sample_text_2-1", No: 1},
+			},
+			assertion: assert.NoError,
+		},
+		{
+			name:      "No matches",
+			opts:      Options{Root: testDirPath, Pattern: "_3"},
+			want:      []Match{},
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Invalid regexp",
+			opts:      Options{Root: testDirPath, Pattern: "("},
+			want:      []Match{},
+			assertion: assert.Error,
+		},
+		{
+			name:      "Invalid root",
+			opts:      Options{Root: "../testdata/not_found", Pattern: "_1"},
+			want:      []Match{},
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			got := make([]Match, 0)
+			for m, e := range Search(context.Background(), tt.opts) {
+				if e != nil {
+					err = e
+					continue
+				}
+				got = append(got, m)
+			}
+
+			tt.assertion(t, err)
+			sort.Slice(got, func(i, j int) bool {
+				if got[i].Path != got[j].Path {
+					return got[i].Path < got[j].Path
+				}
+				return got[i].No < got[j].No
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSearch_Break(t *testing.T) {
+	count := 0
+	for _, err := range Search(context.Background(), Options{Root: testDirPath, Pattern: "sample"}) {
+		assert.NoError(t, err)
+		count++
+		break
+	}
+
+	assert.Equal(t, 1, count)
+}
+
+func TestSearch_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var errs []error
+	for _, err := range Search(ctx, Options{Root: testDirPath, Pattern: "sample"}) {
+		errs = append(errs, err)
+	}
+
+	// キャンセル後は一致行や中断に伴うエラーを返さず、最後に InterruptedError を返す
+	assert.Equal(t, []error{&InterruptedError{Err: context.Canceled}}, errs)
+}
+
+func TestSearch_Stats(t *testing.T) {
+	st := stats.New()
+	for _, err := range Search(context.Background(), Options{Root: testDirPath, Pattern: "_2", Stats: st}) {
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, st.Dirs)
+	assert.Equal(t, 2, st.Files)
+	assert.Equal(t, 1, st.MatchedFiles)
+	assert.Equal(t, 2, st.MatchedLines)
+}
@@ -0,0 +1,63 @@
+package result
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// 色付きで出力する際の ANSI エスケープシーケンス
+const (
+	colorFile   = "\x1b[35m"
+	colorLineNo = "\x1b[32m"
+	colorMatch  = "\x1b[1;31m"
+	colorReset  = "\x1b[0m"
+)
+
+// Store に保存されているファイル名のみを色付きで出力する関数
+func RenderFilesColor(w io.Writer) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	for _, file := range Store.Files() {
+		fmt.Fprintln(w, colorFile+file+colorReset)
+	}
+}
+
+// Store に保存されているファイル名と一致した行の内容、行番号を色付きで出力する関数
+// 行の内容のうち re に一致した部分を強調する
+func RenderWithContentColor(w io.Writer, re *regexp.Regexp) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	for i, file := range Store.Files() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintln(w, colorFile+file+colorReset)
+		for _, line := range Store.Data[file] {
+			fmt.Fprintf(w, "%s%d%s: %s\n", colorLineNo, line.No, colorReset, highlight(line.Text, line.matches(re)))
+		}
+	}
+}
+
+// txt のうち一致箇所を強調した文字列を返す関数
+func highlight(txt string, locs [][]int) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, loc := range locs {
+		// 空文字列への一致と、直前の一致箇所と重なる一致箇所は強調しない
+		if loc[0] == loc[1] || loc[0] < last {
+			continue
+		}
+		b.WriteString(txt[last:loc[0]])
+		b.WriteString(colorMatch + txt[loc[0]:loc[1]] + colorReset)
+		last = loc[1]
+	}
+	b.WriteString(txt[last:])
+	return b.String()
+}
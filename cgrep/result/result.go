@@ -1,7 +1,9 @@
 package result
 
 import (
+	"fmt"
 	"io"
+	"regexp"
 	"sort"
 	"sync"
 )
@@ -9,6 +11,9 @@ import (
 type Line struct {
 	Text string
 	No   int
+	// 行内の一致箇所のバイト単位の範囲 [開始, 終了)
+	// nil の場合は出力時に正規表現で一致箇所を求める
+	Spans [][2]int
 }
 
 type Result struct {
@@ -21,46 +26,65 @@ var Store = &Result{Data: make(map[string][]Line, 100)}
 
 // ファイル名、一致した行の内容、行番号を渡すと var Store に保存する関数
 func Set(fileName, txt string, no int) {
+	SetSpans(fileName, txt, no, nil)
+}
+
+// 行内の一致箇所の範囲とともに一致した行を var Store に保存する関数
+// 構造検索のように一致箇所を正規表現から求められない場合に利用する
+func SetSpans(fileName, txt string, no int, spans [][2]int) {
 	Store.Lock()
 	defer Store.Unlock()
 
 	if _, ok := Store.Data[fileName]; !ok {
 		Store.Data[fileName] = make([]Line, 0, 10)
 	}
-	Store.Data[fileName] = append(Store.Data[fileName], Line{txt, no})
+	Store.Data[fileName] = append(Store.Data[fileName], Line{Text: txt, No: no, Spans: spans})
+}
+
+// 行内の一致箇所ごとに、re.FindAllStringSubmatchIndex と同じ形式の位置を返すメソッド
+// Spans が設定されている場合はその範囲を一致箇所とし、サブマッチは一致しなかったものとする
+func (l Line) matches(re *regexp.Regexp) [][]int {
+	if l.Spans == nil {
+		return re.FindAllStringSubmatchIndex(l.Text, -1)
+	}
+
+	locs := make([][]int, 0, len(l.Spans))
+	for _, span := range l.Spans {
+		loc := make([]int, 2*(re.NumSubexp()+1))
+		for i := range loc {
+			loc[i] = -1
+		}
+		loc[0], loc[1] = span[0], span[1]
+		locs = append(locs, loc)
+	}
+	return locs
 }
 
 // Store に保存されているファイル名のみを出力する関数
 func RenderFiles(w io.Writer) {
-	// TODO: Implement here
-	// 以下の処理を実装する必要があります：
-	// 1. ファイル名の取得
-	//    - 検索結果として保存されているファイル名の一覧を取得する
-	//
-	// 2. 出力処理
-	//    - 取得したファイル名を表示する
-	//    - ファイル名は昇順で表示する
-	//    - フォーマットの詳細は README.md を参照
-	//
-	// ヒント：
-	// - ファイル名をソート済みのスライスで取得できるメソッドが用意されています
+	Store.Lock()
+	defer Store.Unlock()
+
+	for _, file := range Store.Files() {
+		fmt.Fprintln(w, file)
+	}
 }
 
 // Store に保存されているファイル名と一致した行の内容、行番号を出力する関数
 func RenderWithContent(w io.Writer) {
-	// TODO: Implement here
-	// 以下の処理を実装する必要があります：
-	// 1. ファイル名の取得
-	//    - 検索結果として保存されているファイル名の一覧を取得する
-	//
-	// 2. ファイルごとの出力処理
-	//    - ファイル名に加えて、そのファイルでマッチした各行の内容も出力する
-	//    - ファイル名は昇順で表示する
-	//    - フォーマットの詳細は README.md を参照
-	//
-	// ヒント：
-	// - ファイル名をソート済みのスライスで取得できるメソッドが用意されています
-	// - 結果をどのように保存しているかを他の実装から読み解きましょう
+	Store.Lock()
+	defer Store.Unlock()
+
+	for i, file := range Store.Files() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintln(w, file)
+		for _, line := range Store.Data[file] {
+			fmt.Fprintf(w, "%d: %s\n", line.No, line.Text)
+		}
+	}
 }
 
 // 保存されているファイル名を昇順でソートした上で []string として返す関数
@@ -0,0 +1,200 @@
+package result
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// テンプレート中の置換対象の種類
+const (
+	fieldLiteral = iota
+	fieldPath
+	fieldLine
+	fieldText
+	fieldGroup
+)
+
+type field struct {
+	kind  int
+	text  string
+	group int
+}
+
+// {path}, {line}, {text}, {match} とサブマッチの番号・名前を置換して出力するためのテンプレート
+type Template struct {
+	re     *regexp.Regexp
+	fields []field
+	// サブマッチを参照している場合は一致箇所ごとに出力する
+	perMatch bool
+}
+
+// 文字列をテンプレートとして解析する関数
+// "{{", "}}" はそれぞれ "{", "}" として出力される
+func ParseTemplate(tmpl string, re *regexp.Regexp) (*Template, error) {
+	t := &Template{re: re}
+	var lit strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if strings.HasPrefix(tmpl[i:], "{{") || strings.HasPrefix(tmpl[i:], "}}") {
+			lit.WriteByte(tmpl[i])
+			i++
+			continue
+		}
+		if tmpl[i] != '{' {
+			lit.WriteByte(tmpl[i])
+			continue
+		}
+
+		end := strings.IndexByte(tmpl[i:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed placeholder in format: %s", tmpl[i:])
+		}
+		f, err := t.parseField(tmpl[i+1 : i+end])
+		if err != nil {
+			return nil, err
+		}
+
+		if lit.Len() > 0 {
+			t.fields = append(t.fields, field{kind: fieldLiteral, text: lit.String()})
+			lit.Reset()
+		}
+		t.fields = append(t.fields, f)
+		i += end
+	}
+	if lit.Len() > 0 {
+		t.fields = append(t.fields, field{kind: fieldLiteral, text: lit.String()})
+	}
+
+	return t, nil
+}
+
+// 指定されたサブマッチのみを出力するテンプレートを生成する関数
+func CaptureTemplate(re *regexp.Regexp, capture string) (*Template, error) {
+	idx, err := CaptureIndex(re, capture)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template{re: re, fields: []field{{kind: fieldGroup, group: idx}}, perMatch: true}, nil
+}
+
+// プレースホルダの名前を解析するメソッド
+func (t *Template) parseField(name string) (field, error) {
+	switch name {
+	case "path":
+		return field{kind: fieldPath}, nil
+	case "line":
+		return field{kind: fieldLine}, nil
+	case "text":
+		return field{kind: fieldText}, nil
+	case "match":
+		name = "0"
+	}
+
+	idx, err := CaptureIndex(t.re, name)
+	if err != nil {
+		return field{}, err
+	}
+	t.perMatch = true
+	return field{kind: fieldGroup, group: idx}, nil
+}
+
+// サブマッチの番号または名前を渡すと、そのサブマッチのインデックスを返す関数
+func CaptureIndex(re *regexp.Regexp, capture string) (int, error) {
+	if n, err := strconv.Atoi(capture); err == nil {
+		if n < 0 || n > re.NumSubexp() {
+			return 0, fmt.Errorf("capture group %d does not exist", n)
+		}
+		return n, nil
+	}
+
+	if n := re.SubexpIndex(capture); n != -1 {
+		return n, nil
+	}
+	return 0, fmt.Errorf("capture group '%s' does not exist", capture)
+}
+
+// 1 行分の一致箇所ごとに展開した文字列を返すメソッド
+// サブマッチを参照していない場合は行ごとに 1 つだけ返す
+func (t *Template) expand(fileName string, line Line) []string {
+	if !t.perMatch {
+		return []string{t.expandMatch(fileName, line, nil)}
+	}
+
+	matches := line.matches(t.re)
+	ss := make([]string, 0, len(matches))
+	for _, loc := range matches {
+		ss = append(ss, t.expandMatch(fileName, line, loc))
+	}
+	return ss
+}
+
+// 1 つの一致箇所についてテンプレートを展開するメソッド
+func (t *Template) expandMatch(fileName string, line Line, loc []int) string {
+	var b strings.Builder
+	for _, f := range t.fields {
+		switch f.kind {
+		case fieldLiteral:
+			b.WriteString(f.text)
+		case fieldPath:
+			b.WriteString(fileName)
+		case fieldLine:
+			b.WriteString(strconv.Itoa(line.No))
+		case fieldText:
+			b.WriteString(line.Text)
+		case fieldGroup:
+			if start, end := loc[2*f.group], loc[2*f.group+1]; start != -1 {
+				b.WriteString(line.Text[start:end])
+			}
+		}
+	}
+	return b.String()
+}
+
+// Store に保存されている一致箇所ごとにテンプレートを展開して 1 行ずつ出力する関数
+func RenderTemplate(w io.Writer, t *Template) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	for _, file := range Store.Files() {
+		for _, line := range Store.Data[file] {
+			for _, s := range t.expand(file, line) {
+				fmt.Fprintln(w, s)
+			}
+		}
+	}
+}
+
+// Store に保存されているファイル名と、一致箇所ごとに展開したテンプレートを行番号とともに出力する関数
+func RenderCaptures(w io.Writer, t *Template) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	i := 0
+	for _, file := range Store.Files() {
+		ss := make([]string, 0, len(Store.Data[file]))
+		for _, line := range Store.Data[file] {
+			for _, s := range t.expand(file, line) {
+				if s == "" {
+					continue
+				}
+				ss = append(ss, fmt.Sprintf("%d: %s", line.No, s))
+			}
+		}
+		if len(ss) == 0 {
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		i++
+
+		fmt.Fprintln(w, file)
+		for _, s := range ss {
+			fmt.Fprintln(w, s)
+		}
+	}
+}
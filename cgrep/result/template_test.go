@@ -0,0 +1,134 @@
+package result
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testTemplateResult = &Result{
+	Mutex: sync.Mutex{},
+	Data: map[string][]Line{
+		"filename1": {
+			{Text: "code=E100 code=E200", No: 1},
+			{Text: "code=W300", No: 3},
+		},
+		"dir/filename2": {
+			{Text: "metric code=E400", No: 2},
+		},
+	},
+}
+
+func TestParseTemplate(t *testing.T) {
+	re := regexp.MustCompile(`code=(?P<level>[EW])(\d+)`)
+	tests := []struct {
+		name      string
+		tmpl      string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{name: "Builtin fields", tmpl: "{path}:{line}:{text}", assertion: assert.NoError},
+		{name: "Group number", tmpl: "{path}:{2}", assertion: assert.NoError},
+		{name: "Group name", tmpl: "{level}{match}", assertion: assert.NoError},
+		{name: "Escaped brace", tmpl: "{{path}", assertion: assert.NoError},
+		{name: "Unknown group number", tmpl: "{3}", assertion: assert.Error},
+		{name: "Unknown group name", tmpl: "{name}", assertion: assert.Error},
+		{name: "Unclosed placeholder", tmpl: "{path", assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate(tt.tmpl, re)
+			tt.assertion(t, err)
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	re := regexp.MustCompile(`code=(?P<level>[EW])(\d+)`)
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "Per match",
+			tmpl: "{path}:{line}:{level}-{2}",
+			want: "dir/filename2:2:E-400\nfilename1:1:E-100\nfilename1:1:E-200\nfilename1:3:W-300\n",
+		},
+		{
+			name: "Per line",
+			tmpl: "{{{path}}}:{line}:{text}",
+			want: "{dir/filename2}:2:metric code=E400\n{filename1}:1:code=E100 code=E200\n{filename1}:3:code=W300\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer Reset()
+
+			tmpl, err := ParseTemplate(tt.tmpl, re)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := bytes.NewBuffer([]byte{})
+			Store = testTemplateResult
+			RenderTemplate(buf, tmpl)
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestRenderCaptures(t *testing.T) {
+	re := regexp.MustCompile(`code=(?P<level>E)?(\d+)`)
+	tests := []struct {
+		name      string
+		capture   string
+		want      string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "Whole match",
+			capture:   "0",
+			want:      "dir/filename2\n2: code=E400\n\nfilename1\n1: code=E100\n1: code=E200\n",
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Group number",
+			capture:   "2",
+			want:      "dir/filename2\n2: 400\n\nfilename1\n1: 100\n1: 200\n",
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Group name",
+			capture:   "level",
+			want:      "dir/filename2\n2: E\n\nfilename1\n1: E\n1: E\n",
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Unknown group",
+			capture:   "path",
+			want:      "",
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer Reset()
+
+			tmpl, err := CaptureTemplate(re, tt.capture)
+			tt.assertion(t, err)
+			if err != nil {
+				return
+			}
+
+			buf := bytes.NewBuffer([]byte{})
+			Store = testTemplateResult
+			RenderCaptures(buf, tmpl)
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
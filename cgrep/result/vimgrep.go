@@ -0,0 +1,56 @@
+package result
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Store に保存されている一致箇所ごとに "ファイル名:行番号:列番号:行の内容" の形式で 1 行ずつ出力する関数
+// 列番号は行頭を 1 としたバイト単位の位置で、null が true の場合はファイル名の後ろを ":" の代わりに NUL で区切る
+func RenderVimgrep(w io.Writer, re *regexp.Regexp, null bool) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	sep := ":"
+	if null {
+		sep = "\x00"
+	}
+	for _, file := range Store.Files() {
+		for _, line := range Store.Data[file] {
+			for _, col := range columns(line.matches(re)) {
+				fmt.Fprintf(w, "%s%s%d:%d:%s\n", file, sep, line.No, col, line.Text)
+			}
+		}
+	}
+}
+
+// Store に保存されているファイル名のみを NUL 区切りで出力する関数
+func RenderFilesNull(w io.Writer) {
+	Store.Lock()
+	defer Store.Unlock()
+
+	for _, file := range Store.Files() {
+		fmt.Fprint(w, file+"\x00")
+	}
+}
+
+// 行内の一致箇所の列番号を返す関数
+// 空文字列への一致は、他に一致箇所がない場合のみ列番号として扱う
+func columns(locs [][]int) []int {
+	cols := make([]int, 0, 1)
+	empty := 0
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			if empty == 0 {
+				empty = loc[0] + 1
+			}
+			continue
+		}
+		cols = append(cols, loc[0]+1)
+	}
+	if len(cols) == 0 && empty > 0 {
+		cols = append(cols, empty)
+	}
+	return cols
+}
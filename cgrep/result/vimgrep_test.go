@@ -0,0 +1,85 @@
+package result
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderVimgrep(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		null    bool
+		want    string
+	}{
+		{
+			name:    "Per match",
+			pattern: `code=E\d+`,
+			want:    "dir/filename2:2:8:metric code=E400\nfilename1:1:1:code=E100 code=E200\nfilename1:1:11:code=E100 code=E200\n",
+		},
+		{
+			name:    "Empty match",
+			pattern: `^`,
+			want:    "dir/filename2:2:1:metric code=E400\nfilename1:1:1:code=E100 code=E200\nfilename1:3:1:code=W300\n",
+		},
+		{
+			name:    "Null separated",
+			pattern: `W\d+`,
+			null:    true,
+			want:    "filename1\x003:6:code=W300\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer Reset()
+
+			buf := bytes.NewBuffer([]byte{})
+			Store = testTemplateResult
+			RenderVimgrep(buf, regexp.MustCompile(tt.pattern), tt.null)
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestRenderVimgrepSpans(t *testing.T) {
+	defer Reset()
+
+	// 構造検索の結果は正規表現ではなく、一致した要素の範囲から列番号を求める
+	Store = &Result{
+		Mutex: sync.Mutex{},
+		Data: map[string][]Line{
+			"sample.go": {
+				{Text: "\tdefer f.Close()", No: 22, Spans: [][2]int{{7, 14}}},
+				{Text: "\tn, _ = strconv.Atoi(\"2\"), os.Remove(\"sample\")", No: 30, Spans: [][2]int{{8, 20}, {27, 36}}},
+			},
+		},
+	}
+	buf := bytes.NewBuffer([]byte{})
+	RenderVimgrep(buf, regexp.MustCompile(""), false)
+
+	want := "sample.go:22:8:\tdefer f.Close()\n" +
+		"sample.go:30:9:\tn, _ = strconv.Atoi(\"2\"), os.Remove(\"sample\")\n" +
+		"sample.go:30:28:\tn, _ = strconv.Atoi(\"2\"), os.Remove(\"sample\")\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestRenderFilesNull(t *testing.T) {
+	defer Reset()
+
+	buf := bytes.NewBuffer([]byte{})
+	Store = &Result{
+		Mutex: sync.Mutex{},
+		Data: map[string][]Line{
+			"file name": {{Text: "text", No: 1}},
+			"dir/file":  {{Text: "text", No: 2}},
+		},
+	}
+	RenderFilesNull(buf)
+
+	assert.Equal(t, "dir/file\x00file name\x00", buf.String())
+}
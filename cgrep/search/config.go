@@ -0,0 +1,162 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cgrep/stats"
+)
+
+// --type で指定可能なファイルの種類と、その種類に該当するファイル名のパターン
+var Types = map[string][]string{
+	"c":        {"*.c", "*.h"},
+	"cpp":      {"*.cc", "*.cpp", "*.cxx", "*.hh", "*.hpp", "*.hxx"},
+	"css":      {"*.css", "*.scss", "*.sass"},
+	"go":       {"*.go"},
+	"html":     {"*.htm", "*.html"},
+	"java":     {"*.java"},
+	"js":       {"*.js", "*.jsx", "*.mjs", "*.cjs"},
+	"json":     {"*.json"},
+	"markdown": {"*.md", "*.markdown"},
+	"py":       {"*.py"},
+	"ruby":     {"*.rb"},
+	"rust":     {"*.rs"},
+	"sh":       {"*.sh", "*.bash", "*.zsh"},
+	"sql":      {"*.sql"},
+	"toml":     {"*.toml"},
+	"ts":       {"*.ts", "*.tsx"},
+	"txt":      {"*.txt"},
+	"yaml":     {"*.yaml", "*.yml"},
+}
+
+// 検索の挙動を指定するための設定
+type Config struct {
+	// 検索結果の送り先（nil の場合は result, errors, stats の各 Store へ保存される）
+	Reporter Reporter
+	// ファイル名・ディレクトリ名がいずれかのパターンに一致する場合は検索対象から除外する
+	Excludes []string
+	// 空でない場合、いずれかの種類に該当するファイルのみを検索する
+	Types []string
+	// 同時に内容を検索するファイル数の上限（0 の場合は無制限）
+	Jobs int
+	// ファイルの内容の検索方法（nil の場合は正規表現に一致する行を検索する）
+	Grep GrepFunc
+
+	sem chan struct{}
+}
+
+// src の内容を検索し、一致した行を name のファイルの内容として r へ送る関数の型
+type GrepFunc func(ctx context.Context, name string, src io.Reader, r Reporter) error
+
+// 設定内容が正しいかを検証する関数
+func (c *Config) Validate() error {
+	for _, p := range c.Excludes {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern '%s': %s", p, err.Error())
+		}
+	}
+	for _, t := range c.Types {
+		if _, ok := Types[t]; !ok {
+			return fmt.Errorf("unknown file type '%s' (available: %s)", t, strings.Join(typeNames(), ", "))
+		}
+	}
+	if c.Jobs < 0 {
+		return fmt.Errorf("jobs must be 0 or more: %d", c.Jobs)
+	}
+	return nil
+}
+
+// 検索ルートからの相対パスを渡すと、そのファイルを検索対象から外す理由を返すメソッド
+// 検索対象とする場合は空文字を返す
+func (c *Config) SkipReason(relPath string) string {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, part := range parts[:len(parts)-1] {
+		if part == ".git" {
+			return stats.ReasonGitDir
+		}
+		if c.excluded(part) {
+			return stats.ReasonExcluded
+		}
+	}
+
+	name := parts[len(parts)-1]
+	if c.excluded(name) {
+		return stats.ReasonExcluded
+	}
+	if !c.typeMatched(name) {
+		return stats.ReasonType
+	}
+	return ""
+}
+
+// ディレクトリ名を渡すと、その配下を検索対象から外すかを返すメソッド
+func (c *Config) SkipDir(name string) bool {
+	return name == ".git" || c.excluded(name)
+}
+
+// 名前が除外パターンのいずれかに一致するかを検証するメソッド
+func (c *Config) excluded(name string) bool {
+	if c == nil {
+		return false
+	}
+
+	for _, p := range c.Excludes {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ファイル名が指定された種類のいずれかに該当するかを検証するメソッド
+func (c *Config) typeMatched(name string) bool {
+	if c == nil || len(c.Types) == 0 {
+		return true
+	}
+
+	for _, t := range c.Types {
+		for _, p := range Types[t] {
+			if ok, _ := filepath.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 同時に検索するファイル数の上限に達している場合は空きが出るまで待つメソッド
+// 戻り値の関数を呼び出すと枠を解放する
+func (c *Config) acquire(ctx context.Context) (func(), error) {
+	if c == nil || c.sem == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	}
+}
+
+// 同時実行数の制御に必要な初期化を行うメソッド
+func (c *Config) init() {
+	if c != nil && c.Jobs > 0 && c.sem == nil {
+		c.sem = make(chan struct{}, c.Jobs)
+	}
+}
+
+// 利用可能なファイルの種類を昇順で返す関数
+func typeNames() []string {
+	names := make([]string, 0, len(Types))
+	for k := range Types {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"cgrep/stats"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *Config
+		assertion assert.ErrorAssertionFunc
+	}{
+		{name: "Valid", config: &Config{Excludes: []string{"*.min.js"}, Types: []string{"go"}, Jobs: 4}, assertion: assert.NoError},
+		{name: "Invalid exclude pattern", config: &Config{Excludes: []string{"["}}, assertion: assert.Error},
+		{name: "Unknown type", config: &Config{Types: []string{"unknown"}}, assertion: assert.Error},
+		{name: "Negative jobs", config: &Config{Jobs: -1}, assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.assertion(t, tt.config.Validate())
+		})
+	}
+}
+
+func TestConfig_Filter(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       *Config
+		fileName     string
+		wantExcluded bool
+		wantMatched  bool
+	}{
+		{name: "Nil config", config: nil, fileName: "main.go", wantExcluded: false, wantMatched: true},
+		{name: "Excluded", config: &Config{Excludes: []string{"node_*"}}, fileName: "node_modules", wantExcluded: true, wantMatched: true},
+		{name: "Type matched", config: &Config{Types: []string{"markdown", "go"}}, fileName: "README.md", wantExcluded: false, wantMatched: true},
+		{name: "Type not matched", config: &Config{Types: []string{"go"}}, fileName: "README.md", wantExcluded: false, wantMatched: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantExcluded, tt.config.excluded(tt.fileName))
+			assert.Equal(t, tt.wantMatched, tt.config.typeMatched(tt.fileName))
+		})
+	}
+}
+
+func TestConfig_acquire(t *testing.T) {
+	c := &Config{Jobs: 1}
+	c.init()
+
+	release, err := c.acquire(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.acquire(ctx)
+	assert.Error(t, err)
+
+	release()
+	release, err = c.acquire(context.Background())
+	assert.NoError(t, err)
+	release()
+}
+
+func TestConfig_SkipReason(t *testing.T) {
+	c := &Config{Excludes: []string{"vendor", "*.min.js"}, Types: []string{"js"}}
+	tests := []struct {
+		name    string
+		relPath string
+		want    string
+	}{
+		{name: "Allowed", relPath: "src/app.js", want: ""},
+		{name: "Excluded directory", relPath: "vendor/lib/app.js", want: stats.ReasonExcluded},
+		{name: "Excluded file", relPath: "src/app.min.js", want: stats.ReasonExcluded},
+		{name: "Git directory", relPath: "sub/.git/config", want: stats.ReasonGitDir},
+		{name: "Type not matched", relPath: "README.md", want: stats.ReasonType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.SkipReason(tt.relPath))
+		})
+	}
+}
@@ -1,11 +1,23 @@
 package search
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sync"
+
+	"cgrep/stats"
+)
+
+const (
+	// バイナリファイルかを判定するために先頭から読み込むバイト数
+	binaryCheckSize = 512
+	// 1 行として読み込める最大のバイト数
+	maxLineSize = 1024 * 1024
 )
 
 var (
@@ -23,16 +35,26 @@ type dir struct {
 	regexp        *regexp.Regexp
 	subDirs       []Dir
 	fileFullPaths []string
+	config        *Config
 }
 
 // ディレクトリごとに検索用オブジェクトを生成するファクトリ関数
 func New(wg *sync.WaitGroup, fullPath string, re *regexp.Regexp) (Dir, error) {
-	d := &dir{wg: wg, path: fullPath, regexp: re}
+	return NewWithConfig(context.Background(), wg, fullPath, re, nil)
+}
+
+// 設定を指定して検索用オブジェクトを生成するファクトリ関数
+// cfg が nil の場合は全てのファイルを検索し、結果は result, errors, stats の各 Store へ保存される
+// ctx がキャンセルされた場合はスキャンを中断してエラーを返す
+func NewWithConfig(ctx context.Context, wg *sync.WaitGroup, fullPath string, re *regexp.Regexp, cfg *Config) (Dir, error) {
+	cfg.init()
+	d := &dir{wg: wg, path: fullPath, regexp: re, config: cfg}
 	if d.isGitDri() {
+		d.report().Stats().Skip(stats.ReasonGitDir)
 		return d, nil
 	}
 
-	err := d.Scan()
+	err := d.scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -40,23 +62,111 @@ func New(wg *sync.WaitGroup, fullPath string, re *regexp.Regexp) (Dir, error) {
 	return d, nil
 }
 
+// 指定されたファイル・ディレクトリ群を検索するための検索用オブジェクトを生成するファクトリ関数
+// ディレクトリは配下を再帰的に検索し、それ以外は種類を問わずファイルとして読み込む
+// 明示的に指定されたファイルは cfg の除外パターンや種類に関わらず検索する
+// ファイルは親ディレクトリごとにまとめ、ディレクトリ単位で並行して検索する
+// 存在しないパスはスキップし、それ以外の理由で参照できないパスはエラーとして報告した上で残りのパスを検索する
+func NewFiles(ctx context.Context, wg *sync.WaitGroup, fullPaths []string, re *regexp.Regexp, cfg *Config) (Dir, error) {
+	cfg.init()
+	d := &dir{wg: wg, regexp: re, config: cfg}
+	parents := make(map[string]*dir)
+	for _, path := range fullPaths {
+		fi, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			d.report().Stats().Skip(stats.ReasonNotFound)
+			continue
+		}
+		if err != nil {
+			d.report().Error(err)
+			continue
+		}
+
+		if fi.IsDir() {
+			subDir, err := NewWithConfig(ctx, wg, path, re, cfg)
+			if err != nil {
+				return nil, err
+			}
+			d.subDirs = append(d.subDirs, subDir)
+			continue
+		}
+
+		parent, ok := parents[filepath.Dir(path)]
+		if !ok {
+			parent = &dir{wg: wg, path: filepath.Dir(path), regexp: re, config: cfg}
+			parents[parent.path] = parent
+			d.subDirs = append(d.subDirs, parent)
+		}
+		parent.fileFullPaths = append(parent.fileFullPaths, path)
+	}
+
+	return d, nil
+}
+
+// root 配下で cfg に従って検索対象となるファイルのフルパスを返す関数
+// スキャン中の統計情報は破棄する
+func ListFiles(ctx context.Context, root string, cfg *Config) ([]string, error) {
+	c := Config{}
+	if cfg != nil {
+		c = *cfg
+	}
+	c.Reporter = discardReporter{stats: stats.New()}
+
+	d, err := NewWithConfig(ctx, new(sync.WaitGroup), root, nil, &c)
+	if err != nil {
+		return nil, err
+	}
+	return d.(*dir).files(nil), nil
+}
+
+// 自身と配下のディレクトリのファイルのフルパスを files に追加して返すメソッド
+func (d *dir) files(files []string) []string {
+	files = append(files, d.fileFullPaths...)
+	for _, subDir := range d.subDirs {
+		files = subDir.(*dir).files(files)
+	}
+	return files
+}
+
 // func New() を実行した際、自身のサブディレクトリとファイル郡をスキャンする処理
 func (d *dir) Scan() error {
+	return d.scan(context.Background())
+}
+
+// キャンセル可能な形で自身のサブディレクトリとファイル郡をスキャンするメソッド
+func (d *dir) scan(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	fs, err := os.ReadDir(d.path)
 	if err != nil {
 		return err
 	}
+	d.report().Stats().AddDir()
 
 	for _, f := range fs {
 		path := filepath.Join(d.path, f.Name())
+		if d.config.excluded(f.Name()) {
+			d.report().Stats().Skip(stats.ReasonExcluded)
+			continue
+		}
 		if f.IsDir() {
-			subDir, err := New(d.wg, path, d.regexp)
+			subDir, err := NewWithConfig(ctx, d.wg, path, d.regexp, d.config)
 			if err != nil {
 				return err
 			}
 			d.subDirs = append(d.subDirs, subDir)
 			continue
 		}
+		if !f.Type().IsRegular() {
+			d.report().Stats().Skip(stats.ReasonNotRegular)
+			continue
+		}
+		if !d.config.typeMatched(f.Name()) {
+			d.report().Stats().Skip(stats.ReasonType)
+			continue
+		}
 
 		d.fileFullPaths = append(d.fileFullPaths, path)
 	}
@@ -66,22 +176,20 @@ func (d *dir) Scan() error {
 
 // 対象ディレクトリ内のファイルの内容を正規表現で検索し、サブディレクトリに対して再帰的に検索を行うメソッド
 func (d *dir) Search(ctx context.Context) {
-	// TODO: Implement here
-	// 以下の処理を実装する必要があります：
-	// 1. サブディレクトリの検索
-	//    - 配下のディレクトリに対して再帰的に検索を実行する
-	//    - 各ディレクトリの検索は非同期で行う
-	//
-	// 2. ファイル検索の実行
-	//    - 現在のディレクトリ内のファイルに対して検索を実行する
-	//    - エラーが発生した場合は errors パッケージを使用してエラーを設定する
-	//
-	// ヒント：
-	// - このメソッド自体は goroutine として実行される前提で設計しています
-	// . - すべての非同期処理が完了するまで待ち合わせるためにはどうするか考えてみましょう
-	// . - ctx がキャンセルされた場合に非同期処理もキャンセル可能な形で実装する必要があります
-	//   - dir.wg の型である sync.WaitGroup の使い方を調べてみましょう
-	// - エラーは errors パッケージを使用して処理します
+	defer d.wg.Done()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	for _, subDir := range d.subDirs {
+		d.wg.Add(1)
+		go subDir.Search(ctx)
+	}
+
+	if err := d.GrepFiles(ctx); err != nil {
+		d.report().Error(err)
+	}
 }
 
 // 配下のファイルの内容を読み取り、正規表現に一致するファイルを検索するメソッド
@@ -92,21 +200,22 @@ func (d *dir) GrepFiles(ctx context.Context) error {
 		}
 
 		if err := func(path string) error {
-			// TODO: Implement here
-			// 以下の処理を実装する必要があります：
-			// 1. ファイルの準備
-			//    - 指定されたパスのファイルを開く
-			//
-			// 2. ファイル内容の検索
-			//    - ファイルの内容を読み込み、正規表現パターンにマッチする行を検出する
-			//    - 検索結果を適切に保存する
-			//
-			// ヒント：
-			// - ファイルを Open した後は必ず Close するようにしましょう
-			// . - エラーで return された場合でも必ずです
-			// - path は絶対パスで渡されます
-			// - 余裕があれば、読み込むファイルのサイズが大きい場合なども考慮できると素晴らしいです
-			return nil
+			release, err := d.config.acquire(ctx)
+			if err != nil {
+				return nil
+			}
+			defer release()
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if d.config != nil && d.config.Grep != nil {
+				return d.config.Grep(ctx, path, f, d.report())
+			}
+			return GrepReader(ctx, path, f, d.regexp, d.report())
 		}(path); err != nil {
 			return err
 		}
@@ -114,14 +223,75 @@ func (d *dir) GrepFiles(ctx context.Context) error {
 	return nil
 }
 
+// src の内容を正規表現で検索し、一致した行を name のファイルの内容として r へ送る関数
+func GrepReader(ctx context.Context, name string, src io.Reader, re *regexp.Regexp, r Reporter) error {
+	cr := &countReader{r: src}
+	br := bufio.NewReader(cr)
+	head, err := br.Peek(binaryCheckSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return err
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		r.Stats().Skip(stats.ReasonBinary)
+		return nil
+	}
+
+	matched := 0
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for no := 1; scanner.Scan(); no++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		txt := scanner.Text()
+		if re.MatchString(txt) {
+			r.Match(name, txt, no)
+			matched++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			// それまでに一致した行は報告済みのため、検索したファイルとして記録する
+			r.Stats().AddFile(cr.n, matched)
+			r.Stats().Truncate()
+			return nil
+		}
+		return err
+	}
+
+	r.Stats().AddFile(cr.n, matched)
+	return nil
+}
+
 // 自身が .git ディレクトリであるかを検証するメソッド
 func (d *dir) isGitDri() bool {
 	return gitRegExp.MatchString(d.path)
 }
 
-// *os.File を渡すと、ファイル名にカレントディレクトリからそのファイルまでのフルパスを添えて返す関数
-func relativePath(file *os.File) (string, error) {
-	return filepath.Rel(currentDir, file.Name())
+// 検索結果の送り先を返すメソッド
+func (d *dir) report() Reporter {
+	if d.config == nil || d.config.Reporter == nil {
+		return storeReporter{}
+	}
+	return d.config.Reporter
+}
+
+// ファイルのフルパスを渡すと、カレントディレクトリからそのファイルまでの相対パスを返す関数
+func relativePath(fullPath string) (string, error) {
+	return filepath.Rel(currentDir, fullPath)
+}
+
+// 読み込んだバイト数を数えるための io.Reader
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 // 処理の開始時に実行される関数
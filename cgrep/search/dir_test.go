@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"cgrep/result"
+	"cgrep/stats"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -269,3 +270,89 @@ func Test_dir_GrepFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFiles(t *testing.T) {
+	wg := new(sync.WaitGroup)
+
+	tests := []struct {
+		name        string
+		fullPaths   []string
+		want        Dir
+		wantSkipped map[string]int
+		assertion   assert.ErrorAssertionFunc
+	}{
+		{
+			name:      "Files and directories",
+			fullPaths: []string{testFilePath, testSubDirPath},
+			want: &dir{
+				wg:     wg,
+				regexp: testRegExp1,
+				subDirs: []Dir{
+					&dir{
+						wg:            wg,
+						path:          testDirPath,
+						regexp:        testRegExp1,
+						fileFullPaths: []string{testFilePath},
+					},
+					&dir{
+						wg:            wg,
+						path:          testSubDirPath,
+						regexp:        testRegExp1,
+						fileFullPaths: []string{testSubFilePath},
+					},
+				},
+			},
+			assertion: assert.NoError,
+		},
+		{
+			name:      "Not found",
+			fullPaths: []string{filepath.Join(testDirPath, "not_found"), testFilePath},
+			want: &dir{
+				wg:     wg,
+				regexp: testRegExp1,
+				subDirs: []Dir{
+					&dir{
+						wg:            wg,
+						path:          testDirPath,
+						regexp:        testRegExp1,
+						fileFullPaths: []string{testFilePath},
+					},
+				},
+			},
+			wantSkipped: map[string]int{stats.ReasonNotFound: 1},
+			assertion:   assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer stats.Reset()
+
+			got, err := NewFiles(context.Background(), wg, tt.fullPaths, testRegExp1, nil)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+			if tt.wantSkipped == nil {
+				tt.wantSkipped = map[string]int{}
+			}
+			assert.Equal(t, tt.wantSkipped, stats.Store.Skipped)
+		})
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *Config
+		want []string
+	}{
+		{name: "All files", cfg: nil, want: []string{testFilePath, testSubFilePath}},
+		{name: "Excluded", cfg: &Config{Excludes: []string{"dir"}}, want: []string{testFilePath}},
+		{name: "Type", cfg: &Config{Types: []string{"go"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListFiles(context.Background(), testDirPath, tt.cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
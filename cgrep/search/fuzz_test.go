@@ -0,0 +1,77 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"cgrep/result"
+	"cgrep/stats"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 一致した行を保存するだけの Reporter
+type lineReporter struct {
+	lines []result.Line
+	stats *stats.Stats
+}
+
+func (r *lineReporter) Match(fullPath, txt string, no int) {
+	r.lines = append(r.lines, result.Line{Text: txt, No: no})
+}
+
+func (r *lineReporter) Error(err error) {}
+
+func (r *lineReporter) Stats() *stats.Stats {
+	return r.stats
+}
+
+func FuzzGrepReader(f *testing.F) {
+	f.Add("foo\nbar\n", "foo")
+	f.Add("foo\r\nbar\r\nbaz", "^ba")
+	f.Add("\n\n\n", "^$")
+	f.Add("a\x00foo\n", "foo")
+
+	f.Fuzz(func(t *testing.T, content, pattern string) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			t.Skip()
+		}
+
+		r := &lineReporter{stats: stats.New()}
+		assert.NoError(t, GrepReader(context.Background(), "fuzz", strings.NewReader(content), re, r))
+
+		// 行単位で一致を判定した結果と一致する
+		var want []result.Line
+		if !bytes.Contains([]byte(content[:min(len(content), binaryCheckSize)]), []byte{0}) {
+			lines := strings.Split(content, "\n")
+			if lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			for i, line := range lines {
+				line = strings.TrimSuffix(line, "\r")
+				if re.MatchString(line) {
+					want = append(want, result.Line{Text: line, No: i + 1})
+				}
+			}
+		}
+		assert.Equal(t, want, r.lines)
+	})
+}
+
+func TestGrepReader_LongLine(t *testing.T) {
+	content := "foo 1\n" + strings.Repeat("x", maxLineSize+1) + "\nfoo 3\n"
+	r := &lineReporter{stats: stats.New()}
+	assert.NoError(t, GrepReader(context.Background(), "long", strings.NewReader(content), regexp.MustCompile("foo"), r))
+
+	// 長すぎる行より前の一致は報告され、検索したファイルとして集計される
+	assert.Equal(t, []result.Line{{Text: "foo 1", No: 1}}, r.lines)
+	assert.Equal(t, 1, r.stats.Files)
+	assert.Equal(t, 1, r.stats.MatchedFiles)
+	assert.Equal(t, 1, r.stats.MatchedLines)
+	assert.Equal(t, 1, r.stats.Truncated)
+	assert.Empty(t, r.stats.Skipped)
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"path/filepath"
+
+	"cgrep/errors"
+	"cgrep/result"
+	"cgrep/stats"
+)
+
+// 検索中に見つかった一致行、エラー、統計情報の送り先となるインターフェース
+// 各メソッドは複数の goroutine から同時に呼び出される
+// Match の fullPath には、標準入力などファイル以外を検索した場合はその名前が渡される
+type Reporter interface {
+	Match(fullPath, txt string, no int)
+	Error(err error)
+	Stats() *stats.Stats
+}
+
+// 一致した行とともに、行内の一致箇所のバイト単位の範囲 [開始, 終了) を受け取れる Reporter
+// 構造検索のように一致箇所を正規表現から求められない場合は、Match の代わりに MatchSpans が呼び出される
+type SpanReporter interface {
+	Reporter
+	MatchSpans(fullPath, txt string, no int, spans [][2]int)
+}
+
+// result, errors, stats の各 Store へ保存する Reporter
+type storeReporter struct{}
+
+func (r storeReporter) Match(fullPath, txt string, no int) {
+	r.MatchSpans(fullPath, txt, no, nil)
+}
+
+func (storeReporter) MatchSpans(fullPath, txt string, no int, spans [][2]int) {
+	if !filepath.IsAbs(fullPath) {
+		result.SetSpans(fullPath, txt, no, spans)
+		return
+	}
+
+	fileName, err := relativePath(fullPath)
+	if err != nil {
+		errors.Set(err)
+		return
+	}
+
+	result.SetSpans(fileName, txt, no, spans)
+}
+
+func (storeReporter) Error(err error) {
+	errors.Set(err)
+}
+
+func (storeReporter) Stats() *stats.Stats {
+	return stats.Store
+}
+
+// 一致行とエラーを破棄し、統計情報のみを集計する Reporter
+type discardReporter struct {
+	stats *stats.Stats
+}
+
+func (discardReporter) Match(fullPath, txt string, no int) {}
+
+func (discardReporter) Error(err error) {}
+
+func (r discardReporter) Stats() *stats.Stats {
+	return r.stats
+}
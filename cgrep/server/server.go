@@ -0,0 +1,219 @@
+// 検索ルート配下のファイル一覧をメモリ上に保持し、HTTP 経由で検索を受け付けるためのパッケージ
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"cgrep/grep"
+	"cgrep/search"
+)
+
+type Options struct {
+	// 検索ルートとするディレクトリ
+	Root string
+	// ファイル名・ディレクトリ名がいずれかのパターンに一致する場合は検索対象から除外する
+	Excludes []string
+	// 空でない場合、いずれかの種類（search.Types を参照）に該当するファイルのみを検索する
+	Types []string
+	// 1 回の検索で同時に内容を検索するファイル数の上限（0 の場合は無制限）
+	Jobs int
+}
+
+type Server struct {
+	root string
+	cfg  *search.Config
+	jobs int
+
+	mu sync.RWMutex
+	// 検索ルートからの相対パスで表したファイル一覧
+	files []string
+}
+
+// 一致した行を表す JSON Lines の 1 行
+type matchLine struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// 検索中に発生したエラーを表す JSON Lines の 1 行
+type errorLine struct {
+	Error string `json:"error"`
+}
+
+// 設定を検証して検索ルートをスキャンし、Server を生成するファクトリ関数
+func New(ctx context.Context, opts Options) (*Server, error) {
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &search.Config{Excludes: opts.Excludes, Types: opts.Types}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{root: root, cfg: cfg, jobs: opts.Jobs}
+	if err := s.Load(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// 検索ルートをスキャンし直し、保持しているファイル一覧を置き換えるメソッド
+func (s *Server) Load(ctx context.Context) error {
+	fullPaths, err := search.ListFiles(ctx, s.root, s.cfg)
+	if err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(fullPaths))
+	for _, fullPath := range fullPaths {
+		rel, err := filepath.Rel(s.root, fullPath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = files
+	return nil
+}
+
+// ctx がキャンセルされるまで interval ごとに Load を実行するメソッド
+// 失敗した場合はそれまでのファイル一覧を使い続ける
+func (s *Server) Refresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.Load(ctx)
+		}
+	}
+}
+
+// 保持しているファイルのうち、include のいずれかのパターンに一致するものを返すメソッド
+// パターンは検索ルートからの相対パスとファイル名のそれぞれと比較する
+func (s *Server) Files(include []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(include) == 0 {
+		return append([]string{}, s.files...)
+	}
+
+	files := make([]string, 0)
+	for _, f := range s.files {
+		for _, p := range include {
+			if ok, _ := path.Match(p, f); ok {
+				files = append(files, f)
+				break
+			}
+			if ok, _ := path.Match(p, path.Base(f)); ok {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// ルーティングを設定した http.Handler を返すメソッド
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /search", s.handleSearch)
+	return mux
+}
+
+// GET /search?q=PATTERN&include=GLOB
+// 一致した行を見つかった順に JSON Lines 形式で返す
+// クライアントが切断した場合は検索を中断する
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	re, err := regexp.Compile(query.Get("q"))
+	if err != nil || query.Get("q") == "" {
+		msg := "q is required"
+		if err != nil {
+			msg = err.Error()
+		}
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	for _, p := range query["include"] {
+		if _, err := path.Match(p, ""); err != nil {
+			http.Error(w, "invalid include pattern: "+p, http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// スキャン後に削除されたファイルは無視する
+	paths := make([]string, 0)
+	for _, f := range s.Files(query["include"]) {
+		fullPath := filepath.Join(s.root, filepath.FromSlash(f))
+		if _, err := os.Stat(fullPath); err == nil {
+			paths = append(paths, fullPath)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	rep := newJSONReporter(w)
+	opts := grep.Options{Root: s.root, Paths: paths, Pattern: re.String(), BaseDir: s.root, Jobs: s.jobs}
+	for m, err := range grep.Search(r.Context(), opts) {
+		if _, ok := err.(*grep.InterruptedError); ok {
+			// クライアントが切断した場合は送信先がないため何もしない
+			return
+		}
+		if err != nil {
+			rep.Error(err)
+			continue
+		}
+		rep.Match(filepath.ToSlash(m.Path), m.Text, m.No)
+	}
+}
+
+// 一致した行とエラーを JSON Lines としてレスポンスへ書き込む
+type jsonReporter struct {
+	enc *json.Encoder
+	w   http.ResponseWriter
+}
+
+func newJSONReporter(w http.ResponseWriter) *jsonReporter {
+	return &jsonReporter{enc: json.NewEncoder(w), w: w}
+}
+
+func (r *jsonReporter) Match(path, txt string, no int) {
+	r.write(matchLine{Path: path, Line: no, Text: txt})
+}
+
+func (r *jsonReporter) Error(err error) {
+	r.write(errorLine{Error: err.Error()})
+}
+
+// 1 行書き込み、すぐにクライアントへ送信するメソッド
+func (r *jsonReporter) write(v any) {
+	if err := r.enc.Encode(v); err != nil {
+		return
+	}
+	if f, ok := r.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Files(t *testing.T) {
+	s, err := New(context.Background(), Options{Root: "../testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		want    []string
+	}{
+		{name: "All files", include: nil, want: []string{"dir/text.txt", "text.txt"}},
+		{name: "Relative path", include: []string{"dir/*"}, want: []string{"dir/text.txt"}},
+		{name: "File name", include: []string{"*.txt"}, want: []string{"dir/text.txt", "text.txt"}},
+		{name: "Not matched", include: []string{"*.go"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Files(tt.include)
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServer_Load(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "node_modules/b.go", ".git/config"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package a\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := New(context.Background(), Options{Root: root, Excludes: []string{"node_modules"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"a.go"}, s.Files(nil))
+
+	// 再スキャンするまでは追加されたファイルは検索対象にならない
+	if err := os.WriteFile(filepath.Join(root, "c.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"a.go"}, s.Files(nil))
+	assert.NoError(t, s.Load(context.Background()))
+	assert.Equal(t, []string{"a.go", "c.go"}, s.Files(nil))
+}
+
+func TestServer_handleSearch(t *testing.T) {
+	s, err := New(context.Background(), Options{Root: "../testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantStatus int
+		want       []matchLine
+	}{
+		{
+			name:       "Matched",
+			query:      url.Values{"q": {`_\d-1`}},
+			wantStatus: http.StatusOK,
+			want: []matchLine{
+				{Path: "dir/text.txt", Line: 1, Text: "sample_text_2-1"},
+				{Path: "text.txt", Line: 1, Text: "sample_text_1-1"},
+			},
+		},
+		{
+			name:       "Included",
+			query:      url.Values{"q": {`_\d-1`}, "include": {"dir/*"}},
+			wantStatus: http.StatusOK,
+			want: []matchLine{
+				{Path: "dir/text.txt", Line: 1, Text: "sample_text_2-1"},
+			},
+		},
+		{name: "Missing pattern", query: url.Values{}, wantStatus: http.StatusBadRequest},
+		{name: "Invalid pattern", query: url.Values{"q": {"("}}, wantStatus: http.StatusBadRequest},
+		{name: "Invalid include", query: url.Values{"q": {"a"}, "include": {"["}}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.Get(ts.URL + "/search?" + tt.query.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			got := make([]matchLine, 0)
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				var l matchLine
+				assert.NoError(t, json.Unmarshal(scanner.Bytes(), &l))
+				got = append(got, l)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServer_handleSearch_Cancel(t *testing.T) {
+	s, err := New(context.Background(), Options{Root: "../testdata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 切断済みのクライアントからのリクエストでは検索を行わない
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/search?q=sample", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,175 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ファイル・ディレクトリをスキップした理由
+const (
+	ReasonGitDir     = ".git directory"
+	ReasonNotRegular = "not a regular file"
+	ReasonBinary     = "binary file"
+	ReasonExcluded   = "excluded"
+	ReasonType       = "not matched type"
+	ReasonNotFound   = "not found"
+	ReasonSyntax     = "syntax error"
+)
+
+type Stats struct {
+	sync.Mutex
+	Dirs         int
+	Files        int
+	Bytes        int64
+	Skipped      map[string]int
+	MatchedFiles int
+	MatchedLines int
+	ScanTime     time.Duration
+	GrepTime     time.Duration
+	// Files のうち、長すぎる行があったためその行の手前で検索を打ち切ったファイル数
+	Truncated int
+
+	// 集計を開始した時刻
+	start time.Time
+}
+
+// 現在時刻を返す関数（テストで差し替えるために変数にしている）
+var now = time.Now
+
+// CLI での検索中の各種カウンタはこのグローバル変数に集計される
+var Store = New()
+
+// 空の集計用オブジェクトを生成するファクトリ関数
+// 生成した時刻を集計の開始時刻とする
+func New() *Stats {
+	return &Stats{Skipped: make(map[string]int), start: now()}
+}
+
+// スキャンしたディレクトリを 1 件記録するメソッド
+func (s *Stats) AddDir() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Dirs++
+}
+
+// 検索を終えたファイルを 1 件記録し、読み込んだバイト数と一致した行数を加算するメソッド
+func (s *Stats) AddFile(bytes int64, matchedLines int) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Files++
+	s.Bytes += bytes
+	if matchedLines > 0 {
+		s.MatchedFiles++
+		s.MatchedLines += matchedLines
+	}
+}
+
+// 長すぎる行で検索を打ち切ったファイルを 1 件記録するメソッド
+func (s *Stats) Truncate() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Truncated++
+}
+
+// スキップしたファイル・ディレクトリを理由ごとに記録するメソッド
+func (s *Stats) Skip(reason string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Skipped[reason]++
+}
+
+// ディレクトリのスキャンに要した時間を記録するメソッド
+func (s *Stats) SetScanTime(d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.ScanTime = d
+}
+
+// ファイルの内容検索に要した時間を記録するメソッド
+func (s *Stats) SetGrepTime(d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.GrepTime = d
+}
+
+// 集計されている内容を出力するメソッド
+func (s *Stats) Render(w io.Writer) {
+	s.Lock()
+	defer s.Unlock()
+
+	skipped := 0
+	for _, n := range s.Skipped {
+		skipped += n
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "[Stats]")
+	fmt.Fprintf(w, "  Directories: %d\n", s.Dirs)
+	fmt.Fprintf(w, "  Files: %d\n", s.Files)
+	if s.Truncated > 0 {
+		fmt.Fprintf(w, "    truncated at a too long line: %d\n", s.Truncated)
+	}
+	fmt.Fprintf(w, "  Bytes: %d\n", s.Bytes)
+	fmt.Fprintf(w, "  Skipped: %d\n", skipped)
+	for _, reason := range s.reasons() {
+		fmt.Fprintf(w, "    %s: %d\n", reason, s.Skipped[reason])
+	}
+	fmt.Fprintf(w, "  Matched files: %d\n", s.MatchedFiles)
+	fmt.Fprintf(w, "  Matched lines: %d\n", s.MatchedLines)
+	fmt.Fprintf(w, "  Elapsed: %s (scan: %s, grep: %s)\n", now().Sub(s.start), s.ScanTime, s.GrepTime)
+}
+
+// スキップ理由を昇順でソートした上で []string として返すメソッド
+func (s *Stats) reasons() []string {
+	reasons := make([]string, 0, len(s.Skipped))
+	for k := range s.Skipped {
+		reasons = append(reasons, k)
+	}
+
+	sort.Strings(reasons)
+	return reasons
+}
+
+// スキャンしたディレクトリを Store に 1 件記録する関数
+func AddDir() {
+	Store.AddDir()
+}
+
+// 検索を終えたファイルを Store に 1 件記録する関数
+func AddFile(bytes int64, matchedLines int) {
+	Store.AddFile(bytes, matchedLines)
+}
+
+// スキップしたファイル・ディレクトリを Store に理由ごとに記録する関数
+func Skip(reason string) {
+	Store.Skip(reason)
+}
+
+// ディレクトリのスキャンに要した時間を Store に記録する関数
+func SetScanTime(d time.Duration) {
+	Store.SetScanTime(d)
+}
+
+// ファイルの内容検索に要した時間を Store に記録する関数
+func SetGrepTime(d time.Duration) {
+	Store.SetGrepTime(d)
+}
+
+// Store に集計されている内容を出力する関数
+func Render(w io.Writer) {
+	Store.Render(w)
+}
+
+// Store に集計されている内容をリセットする関数
+func Reset() {
+	Store = New()
+}
@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Render のテストで現在時刻として使う時刻
+var testNow = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAddFile(t *testing.T) {
+	type args struct {
+		bytes        int64
+		matchedLines int
+	}
+	tests := []struct {
+		name string
+		args []args
+		want *Stats
+	}{
+		{
+			name: "Matched",
+			args: []args{{bytes: 10, matchedLines: 2}, {bytes: 5, matchedLines: 1}},
+			want: &Stats{
+				Files:        2,
+				Bytes:        15,
+				Skipped:      map[string]int{},
+				MatchedFiles: 2,
+				MatchedLines: 3,
+				start:        testNow,
+			},
+		},
+		{
+			name: "Not matched",
+			args: []args{{bytes: 10, matchedLines: 0}},
+			want: &Stats{
+				Files:   1,
+				Bytes:   10,
+				Skipped: map[string]int{},
+				start:   testNow,
+			},
+		},
+	}
+	now = func() time.Time { return testNow }
+	defer func() { now = time.Now }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+
+			for _, a := range tt.args {
+				AddFile(a.bytes, a.matchedLines)
+			}
+			assert.Equal(t, tt.want, Store)
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	defer Reset()
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			Skip(ReasonBinary)
+		}()
+		go func() {
+			defer wg.Done()
+			AddDir()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int{ReasonBinary: 100}, Store.Skipped)
+	assert.Equal(t, 100, Store.Dirs)
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		set  *Stats
+		want string
+	}{
+		{
+			name: "Success",
+			set: &Stats{
+				Dirs:  2,
+				Files: 3,
+				Bytes: 128,
+				Skipped: map[string]int{
+					ReasonNotRegular: 1,
+					ReasonBinary:     2,
+				},
+				MatchedFiles: 1,
+				MatchedLines: 4,
+				ScanTime:     time.Millisecond,
+				GrepTime:     2 * time.Millisecond,
+				start:        testNow.Add(-5 * time.Millisecond),
+			},
+			want: `
+[Stats]
+  Directories: 2
+  Files: 3
+  Bytes: 128
+  Skipped: 3
+    binary file: 2
+    not a regular file: 1
+  Matched files: 1
+  Matched lines: 4
+  Elapsed: 5ms (scan: 1ms, grep: 2ms)
+`,
+		},
+		{
+			name: "Truncated",
+			set:  &Stats{Files: 2, Bytes: 64, Skipped: map[string]int{}, Truncated: 1, MatchedFiles: 1, MatchedLines: 1, start: testNow},
+			want: `
+[Stats]
+  Directories: 0
+  Files: 2
+    truncated at a too long line: 1
+  Bytes: 64
+  Skipped: 0
+  Matched files: 1
+  Matched lines: 1
+  Elapsed: 0s (scan: 0s, grep: 0s)
+`,
+		},
+		{
+			name: "Empty",
+			set:  &Stats{Skipped: map[string]int{}, start: testNow},
+			want: `
+[Stats]
+  Directories: 0
+  Files: 0
+  Bytes: 0
+  Skipped: 0
+  Matched files: 0
+  Matched lines: 0
+  Elapsed: 0s (scan: 0s, grep: 0s)
+`,
+		},
+	}
+	now = func() time.Time { return testNow }
+	defer func() { now = time.Now }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer Reset()
+
+			buf := bytes.NewBuffer([]byte{})
+			Store = tt.set
+			Render(buf)
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
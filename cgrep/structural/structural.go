@@ -0,0 +1,306 @@
+// ソースコードを構文木として解析し、正規表現の代わりに構文要素で検索するためのパッケージ
+package structural
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"cgrep/search"
+	"cgrep/stats"
+)
+
+// 検索対象とする構文要素の種類
+const (
+	// 関数・メソッドの呼び出し
+	KindCall = "call"
+	// 戻り値を使わずに捨てている関数・メソッドの呼び出し
+	KindUnchecked = "unchecked"
+	// 型の宣言
+	KindType = "type"
+	// 構造体のフィールド
+	KindField = "field"
+)
+
+// 構造検索に対応している言語
+var Langs = []string{"go"}
+
+// 戻り値の型の解決に使う、標準ライブラリのパッケージの型情報を読み込む Importer
+// 複数のファイルを並行して検索するため、排他制御して共有する
+var stdImporter = &lockedImporter{imp: importer.Default()}
+
+// error 型のインターフェース
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+// "種類:名前" の形式の構造検索のクエリ
+type Query struct {
+	Kind string
+	// 要素の名前に対する glob パターン
+	Name string
+}
+
+// 言語とクエリ文字列を解析して Query を返す関数
+func Parse(lang, query string) (*Query, error) {
+	if !slices.Contains(Langs, lang) {
+		return nil, fmt.Errorf("unsupported language '%s' (available: %s)", lang, strings.Join(Langs, ", "))
+	}
+
+	kind, name, ok := strings.Cut(query, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("invalid query '%s' (expected KIND:NAME)", query)
+	}
+	switch kind {
+	case KindCall, KindUnchecked, KindType, KindField:
+	default:
+		return nil, fmt.Errorf("unknown kind '%s' (available: %s, %s, %s, %s)", kind, KindCall, KindUnchecked, KindType, KindField)
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		return nil, fmt.Errorf("invalid name pattern '%s': %s", name, err.Error())
+	}
+
+	return &Query{Kind: kind, Name: name}, nil
+}
+
+// 検索対象とするファイルの種類（search.Types のキー）を返すメソッド
+func (q *Query) Type() string {
+	return "go"
+}
+
+// src を Go のソースコードとして解析し、クエリに一致した要素を含む行を name のファイルの内容として r へ送るメソッド
+// 構文エラーのあるファイルはスキップする
+func (q *Query) Grep(ctx context.Context, name string, src io.Reader, r search.Reporter) error {
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, b, parser.SkipObjectResolution)
+	if err != nil {
+		r.Stats().Skip(stats.ReasonSyntax)
+		return nil
+	}
+
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	if q.Kind == KindUnchecked {
+		// 同じパッケージの他のファイルや標準ライブラリ以外のパッケージは解決できないため、型エラーは無視して解決できた範囲の型情報を使う
+		conf := types.Config{Importer: stdImporter, Error: func(error) {}}
+		_, _ = conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+	}
+
+	// 一致した要素ごとに、開始位置の行とその行内の範囲を求める
+	// 複数行にわたる要素は開始位置の行末までを範囲とする
+	lines := strings.Split(string(b), "\n")
+	spans := make(map[int][][2]int)
+	nos := make([]int, 0)
+	for _, n := range q.find(f, info) {
+		start, end := fset.Position(n.Pos()), fset.Position(n.End())
+		txt := strings.TrimSuffix(lines[start.Line-1], "\r")
+		to := len(txt)
+		if end.Line == start.Line {
+			to = min(end.Column-1, to)
+		}
+		if _, ok := spans[start.Line]; !ok {
+			nos = append(nos, start.Line)
+		}
+		spans[start.Line] = append(spans[start.Line], [2]int{start.Column - 1, to})
+	}
+	slices.Sort(nos)
+
+	sr, withSpans := r.(search.SpanReporter)
+	for _, no := range nos {
+		txt := strings.TrimSuffix(lines[no-1], "\r")
+		if !withSpans {
+			r.Match(name, txt, no)
+			continue
+		}
+		slices.SortFunc(spans[no], func(a, b [2]int) int { return cmp.Compare(a[0], b[0]) })
+		sr.MatchSpans(name, txt, no, spans[no])
+	}
+	r.Stats().AddFile(int64(len(b)), len(nos))
+	return nil
+}
+
+// f のうちクエリに一致した要素を返すメソッド
+// 呼び出しは呼び出している関数の式、型とフィールドは名前の識別子を返す
+// info は戻り値を捨てている呼び出しの判定に使う型情報
+func (q *Query) find(f *ast.File, info *types.Info) []ast.Node {
+	found := make([]ast.Node, 0)
+	// 型宣言の直下にある構造体と、その型名
+	structs := make(map[*ast.StructType]string)
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if q.Kind == KindCall && q.match(callName(n)) {
+				found = append(found, n.Fun)
+			}
+		case *ast.ExprStmt:
+			q.findUnchecked(&found, info, n.X)
+		case *ast.GoStmt:
+			q.findUnchecked(&found, info, n.Call)
+		case *ast.DeferStmt:
+			q.findUnchecked(&found, info, n.Call)
+		case *ast.AssignStmt:
+			q.findUncheckedAssign(&found, info, n)
+		case *ast.TypeSpec:
+			if st, ok := n.Type.(*ast.StructType); ok {
+				structs[st] = n.Name.Name
+			}
+			if q.Kind == KindType && q.match(n.Name.Name) {
+				found = append(found, n.Name)
+			}
+		case *ast.StructType:
+			if q.Kind == KindField {
+				q.findFields(&found, n, structs[n])
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// エラーを返す呼び出しのうち、戻り値を捨てているものがクエリに一致する場合に found へ追加するメソッド
+func (q *Query) findUnchecked(found *[]ast.Node, info *types.Info, x ast.Expr) {
+	call, ok := ast.Unparen(x).(*ast.CallExpr)
+	if q.Kind != KindUnchecked || !ok {
+		return
+	}
+	if q.match(callName(call)) && returnsError(info, call) {
+		*found = append(*found, call.Fun)
+	}
+}
+
+// 代入のうち、エラーを返す位置の代入先が "_" の呼び出しがクエリに一致する場合に found へ追加するメソッド
+// "v, _ := f()" のように 1 つの呼び出しの複数の戻り値を代入する場合は、最後の代入先をエラーの位置とする
+func (q *Query) findUncheckedAssign(found *[]ast.Node, info *types.Info, n *ast.AssignStmt) {
+	if len(n.Rhs) == 1 {
+		if isBlank(n.Lhs[len(n.Lhs)-1]) {
+			q.findUnchecked(found, info, n.Rhs[0])
+		}
+		return
+	}
+	for i, x := range n.Rhs {
+		if i < len(n.Lhs) && isBlank(n.Lhs[i]) {
+			q.findUnchecked(found, info, x)
+		}
+	}
+}
+
+// 構造体 st のフィールドのうちクエリに一致するものを found へ追加するメソッド
+// クエリに "." が含まれる場合は "型名.フィールド名" と比較する
+func (q *Query) findFields(found *[]ast.Node, st *ast.StructType, typeName string) {
+	qualified := strings.Contains(q.Name, ".")
+	if qualified && typeName == "" {
+		return
+	}
+
+	for _, field := range st.Fields.List {
+		names := make([]*ast.Ident, 0, len(field.Names))
+		names = append(names, field.Names...)
+		if len(names) == 0 {
+			// 埋め込みフィールドは型名をフィールド名とする
+			if id := embeddedName(field.Type); id != nil {
+				names = append(names, id)
+			}
+		}
+
+		for _, id := range names {
+			name := id.Name
+			if qualified {
+				name = typeName + "." + name
+			}
+			if q.match(name) {
+				*found = append(*found, id)
+			}
+		}
+	}
+}
+
+// 名前がクエリのパターンに一致するかを返すメソッド
+func (q *Query) match(name string) bool {
+	ok, _ := path.Match(q.Name, name)
+	return ok
+}
+
+// 呼び出している関数の名前を "pkg.Func" や "x.Method" の形式で返す関数
+// 型パラメータの指定は取り除く
+func callName(call *ast.CallExpr) string {
+	fun := ast.Unparen(call.Fun)
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	return types.ExprString(fun)
+}
+
+// 呼び出しの最後の戻り値が error を実装しているかを返す関数
+// 型を解決できない呼び出しは、エラーを返す可能性があるものとして true を返す
+func returnsError(info *types.Info, call *ast.CallExpr) bool {
+	tv, ok := info.Types[call]
+	if !ok || tv.Type == nil {
+		return true
+	}
+	t := tv.Type
+	if tuple, ok := t.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return false
+		}
+		t = tuple.At(tuple.Len() - 1).Type()
+	}
+	if t == types.Typ[types.Invalid] {
+		return true
+	}
+	return types.Implements(t, errorType)
+}
+
+// 埋め込みフィールドの型から、フィールド名となる識別子を返す関数
+func embeddedName(x ast.Expr) *ast.Ident {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	}
+	return nil
+}
+
+// 代入先が "_" であるかを返す関数
+func isBlank(x ast.Expr) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
+// 排他制御して types.Importer を共有するための型
+type lockedImporter struct {
+	mu  sync.Mutex
+	imp types.Importer
+}
+
+// パッケージの型情報を読み込むメソッド
+func (l *lockedImporter) Import(path string) (*types.Package, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.imp.Import(path)
+}
@@ -0,0 +1,228 @@
+package structural
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"cgrep/result"
+	"cgrep/stats"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSource = `package sample
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+type Config struct {
+	Name    string
+	Timeout time.Duration
+	*os.File
+}
+
+type ConfigError struct{ Name string }
+
+func run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	f, _ := os.Open("sample")
+	defer f.Close()
+	os.Remove("sample")
+	_ = os.Remove("sample")
+	if err := os.Remove("sample"); err != nil {
+		return
+	}
+	go func() { _ = struct{ Name string }{} }()
+	n, _ := strconv.Atoi("1")
+	n, _ = strconv.Atoi("2"), os.Remove("sample")
+}
+`
+
+// 一致した行を保存するだけの Reporter
+type lineReporter struct {
+	lines []result.Line
+	stats *stats.Stats
+}
+
+func (r *lineReporter) Match(fullPath, txt string, no int) {
+	r.lines = append(r.lines, result.Line{Text: txt, No: no})
+}
+
+func (r *lineReporter) Error(err error) {}
+
+func (r *lineReporter) Stats() *stats.Stats {
+	return r.stats
+}
+
+// 一致した行の一致箇所の範囲を保存する Reporter
+type spanReporter struct {
+	lineReporter
+	spans map[int][][2]int
+}
+
+func (r *spanReporter) MatchSpans(fullPath, txt string, no int, spans [][2]int) {
+	r.spans[no] = spans
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		lang      string
+		query     string
+		want      *Query
+		assertion assert.ErrorAssertionFunc
+	}{
+		{name: "Call", lang: "go", query: "call:context.WithTimeout", want: &Query{Kind: KindCall, Name: "context.WithTimeout"}, assertion: assert.NoError},
+		{name: "Unsupported language", lang: "rust", query: "call:f", assertion: assert.Error},
+		{name: "Missing name", lang: "go", query: "call:", assertion: assert.Error},
+		{name: "Unknown kind", lang: "go", query: "func:main", assertion: assert.Error},
+		{name: "Invalid pattern", lang: "go", query: "type:[", assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.lang, tt.query)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQuery_Grep(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{name: "Call", query: "call:context.WithTimeout", want: []int{18}},
+		{name: "Call with glob", query: "call:os.*", want: []int{21, 23, 24, 25, 30}},
+		{name: "Method call", query: "call:*.Close", want: []int{22}},
+		{name: "Unchecked call", query: "unchecked:os.Remove", want: []int{23, 24, 30}},
+		{name: "Unchecked deferred call", query: "unchecked:*", want: []int{21, 22, 23, 24, 29, 30}},
+		{name: "Call without results", query: "unchecked:cancel", want: []int{}},
+		// strconv は import されておらず型を解決できないため、エラーを返すものとして扱う
+		{name: "Unchecked error of unresolved call", query: "unchecked:strconv.Atoi", want: []int{29}},
+		{name: "Unchecked call in parallel assignment", query: "unchecked:os.*", want: []int{21, 23, 24, 30}},
+		{name: "Type", query: "type:Config*", want: []int{9, 15}},
+		{name: "Field", query: "field:Name", want: []int{10, 15, 28}},
+		{name: "Qualified field", query: "field:Config.*", want: []int{10, 11, 12}},
+		{name: "Embedded field", query: "field:File", want: []int{12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse("go", tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &lineReporter{stats: stats.New()}
+			assert.NoError(t, q.Grep(context.Background(), "sample.go", strings.NewReader(testSource), r))
+
+			lines := strings.Split(testSource, "\n")
+			want := make([]result.Line, 0, len(tt.want))
+			for _, no := range tt.want {
+				want = append(want, result.Line{Text: lines[no-1], No: no})
+			}
+			got := append([]result.Line{}, r.lines...)
+			assert.Equal(t, want, got)
+			assert.Equal(t, len(tt.want), r.stats.MatchedLines)
+		})
+	}
+}
+
+func TestQuery_Grep_ResultTypes(t *testing.T) {
+	const src = `package sample
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+func run(wg *sync.WaitGroup) {
+	wg.Done()
+	_ = strings.TrimSpace(" ")
+	dir, _ := os.Getwd()
+	_ = count()
+	_ = dir
+	check()
+}
+
+func count() int { return 0 }
+
+func check() error { return nil }
+`
+	q, err := Parse("go", "unchecked:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &lineReporter{stats: stats.New()}
+	assert.NoError(t, q.Grep(context.Background(), "sample.go", strings.NewReader(src), r))
+
+	// 戻り値のない呼び出しと、最後の戻り値がエラーではない呼び出しは一致しない
+	want := []result.Line{
+		{Text: "\tdir, _ := os.Getwd()", No: 12},
+		{Text: "\tcheck()", No: 15},
+	}
+	assert.Equal(t, want, r.lines)
+}
+
+func TestQuery_Grep_SyntaxError(t *testing.T) {
+	q, err := Parse("go", "call:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &lineReporter{stats: stats.New()}
+	assert.NoError(t, q.Grep(context.Background(), "broken.go", strings.NewReader("package broken\nfunc {"), r))
+	assert.Empty(t, r.lines)
+	assert.Equal(t, map[string]int{stats.ReasonSyntax: 1}, r.stats.Skipped)
+}
+
+func TestQuery_Grep_Spans(t *testing.T) {
+	lines := strings.Split(testSource, "\n")
+	// 行 no のうち、部分文字列 sub の範囲を返す関数
+	span := func(no int, sub string) [2]int {
+		i := strings.Index(lines[no-1], sub)
+		return [2]int{i, i + len(sub)}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[int][][2]int
+	}{
+		{name: "One span per call", query: "unchecked:*.Close", want: map[int][][2]int{22: {span(22, "f.Close")}}},
+		{name: "Calls on the same line", query: "call:*", want: map[int][][2]int{
+			18: {span(18, "context.WithTimeout")},
+			19: {span(19, "cancel")},
+			21: {span(21, "os.Open")},
+			22: {span(22, "f.Close")},
+			23: {span(23, "os.Remove")},
+			24: {span(24, "os.Remove")},
+			25: {span(25, "os.Remove")},
+			28: {span(28, "func() { _ = struct{ Name string }{} }")},
+			29: {span(29, "strconv.Atoi")},
+			30: {span(30, "strconv.Atoi"), span(30, "os.Remove")},
+		}},
+		{name: "Field", query: "field:Name", want: map[int][][2]int{10: {span(10, "Name")}, 15: {span(15, "Name")}, 28: {span(28, "Name")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse("go", tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &spanReporter{lineReporter: lineReporter{stats: stats.New()}, spans: make(map[int][][2]int)}
+			assert.NoError(t, q.Grep(context.Background(), "sample.go", strings.NewReader(testSource), r))
+			assert.Equal(t, tt.want, r.spans)
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package client
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BuildRequest で生成したリクエストに認証情報などを追加する関数
+type Decorator func(req *http.Request) error
+
+// BuildRequest の最後に ds を順に適用する Option
+func WithDecorator(ds ...Decorator) Option {
+	return func(c *HttpClient) error {
+		c.decorators = append(c.decorators, ds...)
+		return nil
+	}
+}
+
+// "user:password" の形式の認証情報で Basic 認証を行う Option
+// ":" を含まない場合はパスワードを空とする
+func WithBasicAuth(userpass string) Option {
+	return func(c *HttpClient) error {
+		if userpass == "" {
+			return nil
+		}
+		user, password, _ := strings.Cut(userpass, ":")
+		return WithDecorator(func(req *http.Request) error {
+			req.SetBasicAuth(user, password)
+			return nil
+		})(c)
+	}
+}
+
+// OAuth 2.0 の Bearer トークンを Authorization ヘッダに設定する Option
+func WithBearer(token string) Option {
+	return func(c *HttpClient) error {
+		if token == "" {
+			return nil
+		}
+		return WithDecorator(func(req *http.Request) error {
+			req.Header.Set("Authorization", "Bearer "+token)
+			return nil
+		})(c)
+	}
+}
+
+// netrc ファイルからリクエスト先のホストの認証情報を探し、Basic 認証を行う Option
+// path が空の場合は ~/.netrc を使い、存在しなければ何もしない
+// 他の方法で Authorization ヘッダが設定されている場合はそちらを優先する
+func WithNetrc(path string) Option {
+	return func(c *HttpClient) error {
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil
+			}
+			path = filepath.Join(home, ".netrc")
+			if _, err := os.Stat(path); err != nil {
+				return nil
+			}
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		entries, err := parseNetrc(f)
+		if err != nil {
+			return fmt.Errorf("invalid netrc file '%s': %w", path, err)
+		}
+		return WithDecorator(func(req *http.Request) error {
+			if req.Header.Get("Authorization") != "" {
+				return nil
+			}
+			if e, ok := lookupNetrc(entries, req.URL.Hostname()); ok {
+				req.SetBasicAuth(e.login, e.password)
+			}
+			return nil
+		})(c)
+	}
+}
+
+// "user:password" の形式の認証情報で Digest 認証を行う Option
+func WithDigestAuth(userpass string) Option {
+	return func(c *HttpClient) error {
+		if userpass == "" {
+			return nil
+		}
+		user, password, _ := strings.Cut(userpass, ":")
+		c.digest = &DigestTransport{Username: user, Password: password, Scheme: c.url.Scheme, Host: c.url.Host}
+		return nil
+	}
+}
+
+// netrc ファイルの machine または default の 1 エントリ
+type netrcEntry struct {
+	// default の場合は空
+	machine  string
+	login    string
+	password string
+}
+
+// netrc ファイルを解析する関数
+// macdef で定義されたマクロは空行まで読み飛ばす
+func parseNetrc(r io.Reader) ([]netrcEntry, error) {
+	entries := make([]netrcEntry, 0)
+	inMacro := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			switch token := fields[i]; token {
+			case "default":
+				entries = append(entries, netrcEntry{})
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			case "machine", "login", "password", "account":
+				if i+1 >= len(fields) {
+					return nil, fmt.Errorf("missing value for '%s'", token)
+				}
+				i++
+				if token == "machine" {
+					entries = append(entries, netrcEntry{machine: fields[i]})
+					continue
+				}
+				if len(entries) == 0 {
+					return nil, fmt.Errorf("'%s' appears before 'machine'", token)
+				}
+				switch e := &entries[len(entries)-1]; token {
+				case "login":
+					e.login = fields[i]
+				case "password":
+					e.password = fields[i]
+				}
+			}
+		}
+	}
+	return entries, sc.Err()
+}
+
+// host に一致する machine のエントリを返す関数
+// 一致するものがない場合は default のエントリを返す
+func lookupNetrc(entries []netrcEntry, host string) (netrcEntry, bool) {
+	var def *netrcEntry
+	for i, e := range entries {
+		if e.machine == host {
+			return e, true
+		}
+		if e.machine == "" && def == nil {
+			def = &entries[i]
+		}
+	}
+	if def != nil {
+		return *def, true
+	}
+	return netrcEntry{}, false
+}
+
+// 401 のレスポンスで Digest 認証を要求された場合に、認証情報を付けてリクエストを再送する http.RoundTripper
+// リクエストボディは http.Request.GetBody で作り直すため、GetBody が設定されていないリクエストは再送しない
+type DigestTransport struct {
+	// 実際にリクエストを送信する http.RoundTripper（nil の場合は http.DefaultTransport）
+	Base     http.RoundTripper
+	Username string
+	Password string
+	// 認証情報を送信するスキームとホスト（空の場合は制限しない）
+	// リダイレクト先の別のホストに認証情報を送信しないために使う
+	Scheme string
+	Host   string
+}
+
+func (t *DigestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	res, err := base.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) || !t.allowed(req.URL) {
+		return res, err
+	}
+	ch, ok := parseDigestChallenge(res.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return res, nil
+	}
+
+	r, err := rewind(req)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := ch.authorization(r.Method, r.URL.RequestURI(), t.Username, t.Password, newCnonce())
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	r.Header.Set("Authorization", auth)
+	return base.RoundTrip(r)
+}
+
+// 認証情報を送信してよい URL かを返すメソッド
+func (t *DigestTransport) allowed(u *url.URL) bool {
+	return (t.Scheme == "" || strings.EqualFold(u.Scheme, t.Scheme)) && (t.Host == "" || strings.EqualFold(u.Host, t.Host))
+}
+
+// WWW-Authenticate ヘッダで要求された Digest 認証のパラメータ
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	// 空の場合は RFC 2069 の形式で応答する
+	qop string
+}
+
+// WWW-Authenticate ヘッダの値から Digest 認証のチャレンジを探す関数
+func parseDigestChallenge(values []string) (*digestChallenge, bool) {
+	for _, v := range values {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		params := parseAuthParams(rest)
+		ch := &digestChallenge{realm: params["realm"], nonce: params["nonce"], opaque: params["opaque"], algorithm: params["algorithm"]}
+		if qop, ok := params["qop"]; ok {
+			for _, q := range strings.Split(qop, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					ch.qop = "auth"
+				}
+			}
+			if ch.qop == "" {
+				continue
+			}
+		}
+		return ch, true
+	}
+	return nil, false
+}
+
+// `key=value, key="quoted value"` の形式の認証パラメータを解析する関数
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		if !strings.HasPrefix(rest, `"`) {
+			var value string
+			value, s, _ = strings.Cut(rest, ",")
+			params[key] = strings.TrimSpace(value)
+			continue
+		}
+
+		var b strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+			}
+			b.WriteByte(rest[i])
+		}
+		params[key] = b.String()
+		_, s, _ = strings.Cut(rest[min(i+1, len(rest)):], ",")
+	}
+	return params
+}
+
+// Digest 認証の Authorization ヘッダの値を返すメソッド
+func (ch *digestChallenge) authorization(method, uri, username, password, cnonce string) (string, error) {
+	var newHash func() hash.Hash
+	algorithm := strings.ToUpper(ch.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm '%s'", ch.algorithm)
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	const nc = "00000001"
+	ha1 := h(username + ":" + ch.realm + ":" + password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	ha2 := h(method + ":" + uri)
+
+	var response string
+	if ch.qop == "" {
+		response = h(ha1 + ":" + ch.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + ch.nonce + ":" + nc + ":" + cnonce + ":" + ch.qop + ":" + ha2)
+	}
+
+	params := []string{
+		"username=" + quote(username),
+		"realm=" + quote(ch.realm),
+		"nonce=" + quote(ch.nonce),
+		"uri=" + quote(uri),
+	}
+	if ch.algorithm != "" {
+		params = append(params, "algorithm="+ch.algorithm)
+	}
+	params = append(params, "response="+quote(response))
+	if ch.opaque != "" {
+		params = append(params, "opaque="+quote(ch.opaque))
+	}
+	if ch.qop != "" {
+		params = append(params, "qop="+ch.qop, "nc="+nc, "cnonce="+quote(cnonce))
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// 認証パラメータの値を引用符で囲んだ文字列を返す関数
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Digest 認証でクライアントが生成するランダムな値を返す関数
+func newCnonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_BuildRequest_Auth(t *testing.T) {
+	netrc := writeTestFile(t, "netrc", `# comment
+machine example.com login netrc-user password netrc-pass
+machine other.example.com
+  login other
+  password secret
+macdef init
+  cd /pub
+
+default login anonymous password guest
+`)
+
+	tests := []struct {
+		name    string
+		rawurl  string
+		headers []string
+		opts    []Option
+		want    string
+	}{
+		{name: "Basic", opts: []Option{WithBasicAuth("user:pa:ss")}, want: "Basic dXNlcjpwYTpzcw=="},
+		{name: "Basic without password", opts: []Option{WithBasicAuth("user")}, want: "Basic dXNlcjo="},
+		{name: "Bearer", opts: []Option{WithBearer("token")}, want: "Bearer token"},
+		{name: "Netrc", opts: []Option{WithNetrc(netrc)}, want: "Basic bmV0cmMtdXNlcjpuZXRyYy1wYXNz"},
+		{name: "Netrc multiline", rawurl: "https://other.example.com/", opts: []Option{WithNetrc(netrc)}, want: "Basic b3RoZXI6c2VjcmV0"},
+		{name: "Netrc default", rawurl: "https://unknown.example.com/", opts: []Option{WithNetrc(netrc)}, want: "Basic YW5vbnltb3VzOmd1ZXN0"},
+		{name: "User over netrc", opts: []Option{WithBasicAuth("user:pa:ss"), WithNetrc(netrc)}, want: "Basic dXNlcjpwYTpzcw=="},
+		{name: "Decorator over header", headers: []string{"Authorization: Basic raw"}, opts: []Option{WithBearer("token")}, want: "Bearer token"},
+		{name: "Empty", opts: []Option{WithBasicAuth(""), WithBearer("")}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawurl := tt.rawurl
+			if rawurl == "" {
+				rawurl = "https://example.com/"
+			}
+			c, err := NewHttpClient(rawurl, http.MethodGet, "", tt.headers, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := c.BuildRequest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestWithNetrc_Error(t *testing.T) {
+	_, err := NewHttpClient(testURL, http.MethodGet, "", nil, WithNetrc("./not_exist"))
+	assert.Error(t, err)
+
+	invalid := writeTestFile(t, "netrc", "login user\n")
+	_, err = NewHttpClient(testURL, http.MethodGet, "", nil, WithNetrc(invalid))
+	assert.Error(t, err)
+}
+
+func TestParseAuthParams(t *testing.T) {
+	got := parseAuthParams(`realm="a, \"b\"", qop="auth,auth-int", algorithm=MD5 , stale=FALSE`)
+	assert.Equal(t, map[string]string{
+		"realm":     `a, "b"`,
+		"qop":       "auth,auth-int",
+		"algorithm": "MD5",
+		"stale":     "FALSE",
+	}, got)
+}
+
+func TestDigestChallenge_authorization(t *testing.T) {
+	// RFC 7616 3.9.1 の例
+	header := func(algorithm string) []string {
+		return []string{
+			`Basic realm="http-auth@example.org"`,
+			`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=` + algorithm +
+				`, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+		}
+	}
+	const cnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{algorithm: "MD5", want: `response="8ca523f5e9506fed4657c9700eebdbec"`},
+		{algorithm: "SHA-256", want: `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			ch, ok := parseDigestChallenge(header(tt.algorithm))
+			if !ok {
+				t.Fatal("challenge not found")
+			}
+			got, err := ch.authorization(http.MethodGet, "/dir/index.html", "Mufasa", "Circle of Life", cnonce)
+			assert.NoError(t, err)
+			assert.Contains(t, got, tt.want)
+			assert.Contains(t, got, `qop=auth, nc=00000001, cnonce="`+cnonce+`"`)
+		})
+	}
+
+	ch := &digestChallenge{algorithm: "SHA-512"}
+	_, err := ch.authorization(http.MethodGet, "/", "u", "p", cnonce)
+	assert.Error(t, err)
+}
+
+func TestHttpClient_Execute_Digest(t *testing.T) {
+	const (
+		realm = "murl"
+		nonce = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+	)
+	bodies := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth", nonce="`+nonce+`"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		params := parseAuthParams(strings.TrimPrefix(auth, "Digest "))
+		ch := &digestChallenge{realm: realm, nonce: nonce, qop: "auth"}
+		want, _ := ch.authorization(r.Method, r.URL.RequestURI(), "user", "pass", params["cnonce"])
+		if auth != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		userpass string
+		want     string
+	}{
+		{name: "Authorized", userpass: "user:pass", want: "[Status] 200"},
+		{name: "Wrong password", userpass: "user:wrong", want: "[Status] 401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = bodies[:0]
+			c, err := NewHttpClient(ts.URL+"/path?q=1", http.MethodPost, `{"key":"value"}`, nil, WithDigestAuth(tt.userpass))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, got, err := c.Execute()
+			assert.NoError(t, err)
+			assert.Contains(t, got, tt.want)
+			// チャレンジの後もリクエストボディを送信する
+			assert.Equal(t, []string{`{"key":"value"}`, `{"key":"value"}`}, bodies)
+		})
+	}
+}
+
+func TestHttpClient_Execute_DigestRedirect(t *testing.T) {
+	var gotAuth []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		w.Header().Set("WWW-Authenticate", `Digest realm="other", qop="auth", nonce="abc"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer other.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/login", http.StatusFound)
+	}))
+	defer ts.Close()
+
+	c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithDigestAuth("user:pass"), WithFollowRedirects(DefaultMaxRedirects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, got, err := c.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, got, "[Status] 401")
+	// 別のホストのチャレンジには認証情報を送信しない
+	assert.Equal(t, []string{""}, gotAuth)
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// "name=content", "=content", "content", "name@file", "@file" の形式の値を URL エンコードし、
+// "&" で連結してリクエストボディとする Option（curl の --data-urlencode 相当）
+func WithDataURLEncode(values []string) Option {
+	return func(c *HttpClient) error {
+		if len(values) == 0 {
+			return nil
+		}
+
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			part, err := urlEncodePart(v)
+			if err != nil {
+				return err
+			}
+			parts = append(parts, part)
+		}
+		c.setBody(strings.Join(parts, "&"), "application/x-www-form-urlencoded")
+		return nil
+	}
+}
+
+// --data-urlencode の値 1 つを URL エンコードした文字列を返す関数
+func urlEncodePart(v string) (string, error) {
+	eq := strings.IndexByte(v, '=')
+	at := strings.IndexByte(v, '@')
+	switch {
+	case eq != -1 && (at == -1 || eq < at):
+		// name=content, =content
+		name, content := v[:eq], v[eq+1:]
+		if name == "" {
+			return url.QueryEscape(content), nil
+		}
+		return name + "=" + url.QueryEscape(content), nil
+	case at != -1:
+		// name@file, @file
+		name := v[:at]
+		b, err := os.ReadFile(v[at+1:])
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			return url.QueryEscape(string(b)), nil
+		}
+		return name + "=" + url.QueryEscape(string(b)), nil
+	}
+	return url.QueryEscape(v), nil
+}
+
+// "name=value" または "name=@path[;type=CONTENT_TYPE]" の形式のフィールドを
+// multipart/form-data のリクエストボディとする Option（curl の -F 相当）
+func WithForm(fields []string) Option {
+	return func(c *HttpClient) error {
+		if len(fields) == 0 {
+			return nil
+		}
+
+		buf := new(bytes.Buffer)
+		w := multipart.NewWriter(buf)
+		for _, f := range fields {
+			if err := writeFormField(w, f); err != nil {
+				return err
+			}
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		// ユーザーが multipart の Content-Type を指定した場合は、境界文字列を付け加える
+		if v := c.requestHeader["Content-Type"]; len(v) > 0 {
+			if mediaType, params, err := mime.ParseMediaType(v[0]); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+				params["boundary"] = w.Boundary()
+				c.requestHeader["Content-Type"] = []string{mime.FormatMediaType(mediaType, params)}
+			}
+		}
+		c.setBody(buf.String(), w.FormDataContentType())
+		return nil
+	}
+}
+
+// -F の値 1 つを multipart のパートとして書き込む関数
+func writeFormField(w *multipart.Writer, field string) error {
+	name, value, ok := strings.Cut(field, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid form field: %s", field)
+	}
+	if !strings.HasPrefix(value, "@") {
+		return w.WriteField(name, value)
+	}
+
+	path, contentType, _ := strings.Cut(value[1:], ";type=")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": filepath.Base(path)}))
+	h.Set("Content-Type", contentType)
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(b)
+	return err
+}
+
+// 値をそのまま、または "@path" の場合はファイルの内容をそのままリクエストボディとする Option（curl の --data-binary 相当）
+func WithDataBinary(data string) Option {
+	return func(c *HttpClient) error {
+		if data == "" {
+			return nil
+		}
+
+		if strings.HasPrefix(data, "@") {
+			b, err := os.ReadFile(data[1:])
+			if err != nil {
+				return err
+			}
+			data = string(b)
+		}
+		c.setBody(data, "application/octet-stream")
+		return nil
+	}
+}
+
+// 値を解釈せずにそのままリクエストボディとする Option（curl の --data-raw 相当）
+func WithDataRaw(data string) Option {
+	return func(c *HttpClient) error {
+		if data == "" {
+			return nil
+		}
+
+		c.setBody(data, "application/x-www-form-urlencoded")
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewHttpClient_Body(t *testing.T) {
+	file := writeTestFile(t, "data.txt", "a b&c")
+	tests := []struct {
+		name            string
+		headers         []string
+		opt             Option
+		wantBody        string
+		wantContentType string
+		assertion       assert.ErrorAssertionFunc
+	}{
+		{
+			name:            "URL encoded",
+			opt:             WithDataURLEncode([]string{"name=a b", "=c&d", "e=f", "file@" + file}),
+			wantBody:        "name=a+b&c%26d&e=f&file=a+b%26c",
+			wantContentType: "application/x-www-form-urlencoded",
+			assertion:       assert.NoError,
+		},
+		{
+			name:            "Binary from file",
+			opt:             WithDataBinary("@" + file),
+			wantBody:        "a b&c",
+			wantContentType: "application/octet-stream",
+			assertion:       assert.NoError,
+		},
+		{
+			name:            "Raw",
+			opt:             WithDataRaw("@not_a_file"),
+			wantBody:        "@not_a_file",
+			wantContentType: "application/x-www-form-urlencoded",
+			assertion:       assert.NoError,
+		},
+		{
+			name:            "User content type",
+			headers:         []string{"Content-Type: text/plain"},
+			opt:             WithDataRaw("hello"),
+			wantBody:        "hello",
+			wantContentType: "text/plain",
+			assertion:       assert.NoError,
+		},
+		{
+			name:      "File not found",
+			opt:       WithDataBinary("@" + filepath.Join(t.TempDir(), "not_found")),
+			assertion: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewHttpClient("https://example.com", "POST", "", tt.headers, tt.opt)
+			tt.assertion(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantBody, *got.requestBody)
+			assert.Equal(t, []string{tt.wantContentType}, got.requestHeader["Content-Type"])
+		})
+	}
+}
+
+func TestWithForm(t *testing.T) {
+	file := writeTestFile(t, "image.png", "\x89PNG")
+	got, err := NewHttpClient("https://example.com", "POST", "", nil, WithForm([]string{"name=value", "file=@" + file}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(got.requestHeader["Content-Type"][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "multipart/form-data", mediaType)
+
+	r := multipart.NewReader(strings.NewReader(*got.requestBody), params["boundary"])
+	want := []struct{ name, fileName, contentType, content string }{
+		{name: "name", content: "value"},
+		{name: "file", fileName: "image.png", contentType: "image/png", content: "\x89PNG"},
+	}
+	for _, w := range want {
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(part)
+		assert.Equal(t, w.name, part.FormName())
+		assert.Equal(t, w.fileName, part.FileName())
+		if w.contentType != "" {
+			assert.Equal(t, w.contentType, part.Header.Get("Content-Type"))
+		}
+		assert.Equal(t, w.content, string(b))
+	}
+	_, err = r.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewHttpClient("https://example.com", "POST", "", nil, WithForm([]string{"invalid"}))
+	assert.Error(t, err)
+}
+
+func TestWithForm_ContentType(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		mediaType string
+		params    map[string]string
+	}{
+		{name: "Default", header: "", mediaType: "multipart/form-data"},
+		{name: "Multipart without boundary", header: "Content-Type: multipart/form-data", mediaType: "multipart/form-data"},
+		{name: "Multipart with parameters", header: "Content-Type: multipart/mixed; charset=utf-8; boundary=old", mediaType: "multipart/mixed", params: map[string]string{"charset": "utf-8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := []string{}
+			if tt.header != "" {
+				headers = append(headers, tt.header)
+			}
+			got, err := NewHttpClient("https://example.com", "POST", "", headers, WithForm([]string{"name=value"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Len(t, got.requestHeader["Content-Type"], 1)
+			mediaType, params, err := mime.ParseMediaType(got.requestHeader["Content-Type"][0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.mediaType, mediaType)
+			for k, v := range tt.params {
+				assert.Equal(t, v, params[k])
+			}
+
+			// 付け加えた境界文字列でリクエストボディを読み込める
+			r := multipart.NewReader(strings.NewReader(*got.requestBody), params["boundary"])
+			part, err := r.NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "name", part.FormName())
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package client
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 )
 
 type HttpClient struct {
@@ -14,55 +17,122 @@ type HttpClient struct {
 	method        string
 	requestBody   *string
 	requestHeader map[string][]string
+	// "Name:" の形式で送信しないよう指定されたヘッダ名
+	removedHeaders map[string]bool
+
+	// 接続のタイムアウト
+	connectTimeout time.Duration
+	// リクエスト全体（リトライを含む）のタイムアウト
+	maxTime time.Duration
+	// nil でない場合は RetryTransport で再送する
+	retry *RetryTransport
+
+	// リダイレクトを追跡するか
+	followRedirects bool
+	// リダイレクトを追跡する最大回数（負の場合は無制限）
+	maxRedirects int
+
+	// BuildRequest の最後に順に適用する関数
+	decorators []Decorator
+	// nil でない場合は DigestTransport で Digest 認証を行う
+	digest *DigestTransport
+	// nil でない場合は TLS の接続に使う
+	tlsConfig *tls.Config
+	// レスポンスに TLS の接続の情報を出力するか
+	showTLS bool
+	// nil でない場合は接続の経過とヘッダを出力する
+	verbose *verboseLogger
+	// nil でない場合はボディの表示を加工する
+	renderer *bodyRenderer
+	// 0 より大きい場合はこのバイト数からダウンロードを再開する
+	resumeOffset int64
+	// 受信したボディを Content-Encoding に従って展開するか
+	compressed bool
+	// nil でない場合はボディを受信する進捗を出力する
+	progress io.Writer
+	// nil でない場合はクッキーを送受信する
+	cookies *cookieJar
+	// nil でない場合は環境変数の代わりにこの設定でプロキシを選ぶ
+	proxy *proxyConfig
+	// nil でない場合はこのプロトコルのみを使う
+	protocols *http.Protocols
 }
 
+// NewHttpClient に渡してクライアントの設定を変更するための関数
+type Option func(c *HttpClient) error
+
 // HTTP リクエストを送信するためのクライアントを生成する関数
+// data が空でない場合はメソッドに関わらずリクエストボディとして送信する
+// opts でリクエストボディが設定された場合は data より優先する
+// リクエストボディがある場合、Content-Type はユーザーが指定したものを優先する
 func NewHttpClient(
 	rawurl string,
 	method string,
 	data string,
 	customHeaders []string,
+	opts ...Option,
 ) (*HttpClient, error) {
-	// TODO: Implement here
-	// HTTPクライアントを初期化する処理を実装してください：
-	// 1. URLの構築
-	//    - url パッケージを利用して `url.URL` オブジェクトを生成
-	//
-	// 2. リクエストヘッダの構築
-	//    - カスタムヘッダー []string をパースして map[string][]string に変換
-	// .  - map[string][]string のキーはヘッダー名、値はヘッダー値のリスト
-	//
-	// 3. メソッドに応じたリクエストボディとヘッダーの設定
-	//    - GET, DELETE の場合:
-	//      - リクエストボディは不要 (nil)
-	//      - Content-Type ヘッダーを削除
-	//    - POST, PUT, PATCH の場合:
-	//      - リクエストボディが必須（空文字列の場合はエラー）
-	//      - Content-Type ヘッダーを "application/json" のみに上書き
-	//
-	// ヒント：
-	// - http パッケージには標準的な HTTP メソッドが定数として定義されています
-	// - switch 文を使用してメソッドごとの処理を分岐させることができます
-	return nil, nil
+	u, err := url.ParseRequestURI(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &HttpClient{url: u, method: method, requestHeader: make(map[string][]string, len(customHeaders))}
+	for _, s := range customHeaders {
+		h, err := parseHeader(s)
+		if err != nil {
+			return nil, err
+		}
+		if h.remove {
+			if c.removedHeaders == nil {
+				c.removedHeaders = make(map[string]bool)
+			}
+			c.removedHeaders[h.name] = true
+			delete(c.requestHeader, h.name)
+			continue
+		}
+		c.requestHeader[h.name] = append(c.requestHeader[h.name], h.value)
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.requestBody == nil && data != "" {
+		c.setBody(data, "application/json")
+	}
+	if c.requestBody == nil {
+		delete(c.requestHeader, "Content-Type")
+	}
+	return c, nil
+}
+
+// リクエストボディを設定するメソッド
+// Content-Type が指定されていない場合は contentType を設定する
+func (c *HttpClient) setBody(body, contentType string) {
+	c.requestBody = &body
+	c.setDefaultHeader("Content-Type", contentType)
 }
 
+// ヘッダがユーザーによって指定・削除されていない場合に値を設定するメソッド
+func (c *HttpClient) setDefaultHeader(name, value string) {
+	if _, ok := c.requestHeader[name]; ok || c.removedHeaders[name] {
+		return
+	}
+	c.requestHeader[name] = []string{value}
+}
+
+// リクエストを送信し、リクエストとレスポンスを所定のフォーマットにした文字列を返すメソッド
+// リダイレクトを追跡した場合は、各リダイレクトをレスポンスの前に出力する
 func (c *HttpClient) Execute() (string, string, error) {
-	// TODO: Implement here
-	// HTTPリクエストを実行し、リクエストとレスポンスの文字列表現を返却する処理を実装してください：
-	// 1. BuildRequest() を使用してリクエストを生成
-	// 2. リクエスト実行とエラーハンドリング
-	//    - http.DefaultClient.Do(req) でリクエストを送信
-	//    - エラーの場合は空文字列とエラーを返却
-	// 3. リソースのクリーンアップ
-	//    - レスポンスボディは必ず Close すること（defer を使用）
-	// 4. レスポンスの文字列化
-	//    - CreateRequestText() でリクエスト情報を文字列化
-	//    - CreateResponseText() でレスポンス情報を文字列化
-	//
-	// ヒント：
-	// - http.Response.Body は io.ReadCloser インターフェースを実装しています
-	// - レスポンスボディの Close 漏れは重大なリソースリークの原因となります
-	return "", "", nil
+	ex, err := c.Do()
+	if err != nil {
+		return "", "", err
+	}
+	defer ex.Close()
+
+	return ex.RequestText(), ex.ResponseText(), nil
 }
 
 func (c *HttpClient) BuildRequest() (*http.Request, error) {
@@ -87,51 +157,76 @@ func (c *HttpClient) BuildRequest() (*http.Request, error) {
 	for key, values := range c.requestHeader {
 		req.Header[key] = values
 	}
+	// User-Agent は値が空の場合に送信されない
+	if c.removedHeaders["User-Agent"] {
+		req.Header["User-Agent"] = []string{""}
+	}
+
+	for _, d := range c.decorators {
+		if err := d(req); err != nil {
+			return nil, err
+		}
+	}
 
 	return req, nil
 }
 
 // リクエストURL,HTTPメソッド,リクエストヘッダを所定のフォーマットで返却
 func CreateRequestText(req *http.Request) string {
-	// TODO: Implement here
-	// 以下の順序で情報を文字列化してください：
-	// 1. リクエストセクションの開始
-	//    - "\n===Request===\n" で開始（前後に改行）
-	// 2. URL
-	//    - [URL] の後に req.URL.String() の値を表示
-	// 3. HTTPメソッド
-	//    - [Method] の後に req.Method の値を表示
-	// 4. ヘッダー
-	//    - [Headers] の次行にヘッダー情報を一覧表示
-	//    - ヘッダーキーを昇順でソート（sortedKeys()関数を使用）
-	//    - 各ヘッダーは "  {key}: {value}" の形式で表示（先頭に2つの空白）
-	//    - ヘッダー値が複数ある場合は "; " で結合
-	//
-	// ヒント：
-	// - 文字列の操作には strings パッケージなどを利用すると便利です
-	return ""
+	var b strings.Builder
+	b.WriteString("\n===Request===\n")
+	fmt.Fprintf(&b, "[URL] %s\n", req.URL.String())
+	fmt.Fprintf(&b, "[Method] %s\n", req.Method)
+	b.WriteString(headerText(req.Header))
+	return b.String()
 }
 
-// レスポンスのステータスコード,レスポンスヘッダ,レスポンスボディを所定のフォーマットで返却
+// レスポンスのステータスコード,プロトコル,レスポンスヘッダ,レスポンスボディを所定のフォーマットで返却
+// HEAD リクエストに対するレスポンスの場合はボディを出力しない
 func CreateResponseText(res *http.Response) string {
-	// TODO: Implement here
-	// 以下の順序で情報を文字列化してください：
-	// 1. レスポンスセクションの開始
-	//    - "\n===Response===\n" で開始（前後に改行）
-	// 2. ステータスコード
-	//    - [Status] の後に res.StatusCode を表示
-	// 3. ヘッダー
-	//    - [Headers] の次行にヘッダー情報を一覧表示
-	//    - ヘッダーキーを昇順でソート（sortedKeys()関数を使用）
-	//    - 各ヘッダーは "  {key}: {value}" の形式で表示（先頭に2つの空白）
-	//    - ヘッダー値が複数ある場合は "; " で結合
-	// 4. ボディ
-	//    - [Body] の次行にレスポンスボディを表示
-	// .  - ボディが空の場合は改行のみを表示
-	//
-	// ヒント：
-	// - 文字列の操作には strings パッケージなどを利用すると便利です
-	return ""
+	return createResponseText(res, readBody(res))
+}
+
+// CreateResponseText と同じフォーマットで、読み込み済みのボディと [Headers] の後に sections を出力した文字列を返す関数
+func createResponseText(res *http.Response, body []byte, sections ...string) string {
+	var b strings.Builder
+	b.WriteString("\n===Response===\n")
+	fmt.Fprintf(&b, "[Status] %d\n", res.StatusCode)
+	if res.Proto != "" {
+		fmt.Fprintf(&b, "[Protocol] %s\n", res.Proto)
+	}
+	b.WriteString(headerText(res.Header))
+	for _, s := range sections {
+		b.WriteString(s)
+	}
+	if res.Request != nil && res.Request.Method == http.MethodHead {
+		return b.String()
+	}
+
+	b.WriteString("[Body]\n")
+	b.Write(body)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// レスポンスのボディを全て読み込む関数
+// 読み込みに失敗した場合はその旨のメッセージをボディとする
+func readBody(res *http.Response) []byte {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return []byte(fmt.Sprintf("(failed to read body: %s)", err.Error()))
+	}
+	return body
+}
+
+// ヘッダを "[Headers]" 行に続けてキーの昇順で出力した文字列を返す関数
+func headerText(header map[string][]string) string {
+	var b strings.Builder
+	b.WriteString("[Headers]\n")
+	for _, key := range sortedKeys(header) {
+		fmt.Fprintf(&b, "  %s: %s\n", key, strings.Join(header[key], "; "))
+	}
+	return b.String()
 }
 
 // http.Request.Header と http.Response.Header を渡すと昇順にソートされた Key を返す関数
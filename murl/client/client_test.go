@@ -39,7 +39,7 @@ func TestNewHttpClient(t *testing.T) {
 			args: args{
 				rawurl: rawURL,
 				method: "GET",
-				data:   data,
+				data:   "",
 				customHeaders: []string{
 					"Connection: keep-alive",
 					"Content-Type: application/json",
@@ -55,6 +55,27 @@ func TestNewHttpClient(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "get request with data",
+			args: args{
+				rawurl: rawURL,
+				method: "GET",
+				data:   data,
+				customHeaders: []string{
+					"Connection: keep-alive",
+				},
+			},
+			want: &HttpClient{
+				url:         url,
+				method:      "GET",
+				requestBody: &data,
+				requestHeader: map[string][]string{
+					"Connection":   {"keep-alive"},
+					"Content-Type": {"application/json"},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "post request",
 			args: args{
@@ -123,7 +144,7 @@ func TestNewHttpClient(t *testing.T) {
 			args: args{
 				rawurl: rawURL,
 				method: "DELETE",
-				data:   data,
+				data:   "",
 				customHeaders: []string{
 					"Connection: keep-alive",
 					"Content-Type: application/json",
@@ -140,7 +161,7 @@ func TestNewHttpClient(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "empty data json",
+			name: "post request without data",
 			args: args{
 				rawurl: rawURL,
 				method: "POST",
@@ -149,8 +170,33 @@ func TestNewHttpClient(t *testing.T) {
 					"Connection: keep-alive",
 				},
 			},
-			want:    nil,
-			wantErr: true,
+			want: &HttpClient{
+				url:         url,
+				method:      "POST",
+				requestBody: nil,
+				requestHeader: map[string][]string{
+					"Connection": {"keep-alive"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom method request",
+			args: args{
+				rawurl:        rawURL,
+				method:        "PROPFIND",
+				data:          data,
+				customHeaders: []string{},
+			},
+			want: &HttpClient{
+				url:         url,
+				method:      "PROPFIND",
+				requestBody: &data,
+				requestHeader: map[string][]string{
+					"Content-Type": {"application/json"},
+				},
+			},
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -406,6 +452,7 @@ func TestHttpClient_BuildRequest(t *testing.T) {
 func TestCreateResponseText(t *testing.T) {
 	tests := []struct {
 		name    string
+		method  string
 		headers map[string][]string
 		body    string
 		want    string
@@ -456,16 +503,39 @@ func TestCreateResponseText(t *testing.T) {
 [Headers]
 [Body]
 
+`,
+		},
+		{
+			name:   "head_request",
+			method: http.MethodHead,
+			headers: map[string][]string{
+				"Content-Length": {"1256"},
+			},
+			body: "",
+			want: `
+===Response===
+[Status] 200
+[Headers]
+  Content-Length: 1256
 `,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, testURL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
 			res := &http.Response{
 				Status:     "200 OK",
 				StatusCode: 200,
 				Body:       io.NopCloser(bytes.NewBufferString(tt.body)),
 				Header:     make(http.Header),
+				Request:    req,
 			}
 			defer res.Body.Close()
 			for k, v := range tt.headers {
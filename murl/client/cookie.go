@@ -0,0 +1,423 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Netscape 形式のクッキーファイルの先頭に出力するコメント
+const netscapeHeader = "# Netscape HTTP Cookie File\n# This file was generated by murl. Edit at your own risk.\n\n"
+
+// Netscape 形式で HttpOnly のクッキーの行に付ける接頭辞
+const httpOnlyPrefix = "#HttpOnly_"
+
+// クッキーを送信する Option（curl の -b 相当）
+// "NAME=VALUE; NAME2=VALUE2" の形式の場合はそのまま送信し、
+// "=" を含まない場合は Netscape 形式または JSON のクッキーファイルとして読み込み、URL に合うクッキーを送信する
+// 存在しないファイルは無視する
+func WithCookies(cookies []string) Option {
+	return func(c *HttpClient) error {
+		for _, s := range cookies {
+			if !strings.Contains(s, "=") {
+				if err := c.cookieJar().load(s); err != nil {
+					return err
+				}
+				continue
+			}
+			parsed, err := http.ParseCookie(s)
+			if err != nil {
+				return fmt.Errorf("invalid cookie '%s': %w", s, err)
+			}
+			c.decorators = append(c.decorators, func(req *http.Request) error {
+				for _, cookie := range parsed {
+					req.AddCookie(cookie)
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+}
+
+// 受信したクッキーを SaveCookieJar で path へ保存する Option（curl の -c 相当）
+// path の拡張子が .json の場合は JSON、それ以外は Netscape 形式で保存する
+func WithCookieJar(path string) Option {
+	return func(c *HttpClient) error {
+		if path == "" {
+			return nil
+		}
+		c.cookieJar().path = path
+		return nil
+	}
+}
+
+// クッキーを保持する cookieJar を返すメソッド
+// 最初に呼び出した場合は生成する
+func (c *HttpClient) cookieJar() *cookieJar {
+	if c.cookies == nil {
+		c.cookies = &cookieJar{entries: make(map[string]*cookieEntry), now: time.Now}
+	}
+	return c.cookies
+}
+
+// 読み込んだクッキーと受信したクッキーを WithCookieJar で指定したファイルへ保存するメソッド
+// 期限切れのクッキーは保存しない。ファイルが指定されていない場合は何もしない
+func (c *HttpClient) SaveCookieJar() error {
+	if c.cookies == nil || c.cookies.path == "" {
+		return nil
+	}
+	return c.cookies.save()
+}
+
+// ドメイン・パス・有効期限・Secure の規則（RFC 6265）に従ってクッキーを保持する http.CookieJar
+type cookieJar struct {
+	mu sync.Mutex
+	// ドメイン・パス・名前をキーとするクッキー
+	entries map[string]*cookieEntry
+	// 保存先のファイル
+	path string
+	now  func() time.Time
+	// 同じ時刻に保存したクッキーの順序を保つための連番
+	seq int64
+}
+
+// 保持しているクッキー
+type cookieEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// 先頭の "." を除いたドメイン
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// nil の場合はセッションクッキー
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"http_only"`
+	// Domain 属性がなく、設定したホストにのみ送信するか
+	HostOnly bool `json:"host_only"`
+
+	seq int64
+}
+
+// クッキーを一意に識別するキーを返すメソッド
+func (e *cookieEntry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+// 有効期限が切れているかを返すメソッド
+func (e *cookieEntry) expired(now time.Time) bool {
+	return e.Expires != nil && !e.Expires.After(now)
+}
+
+// レスポンスで受信したクッキーを u の規則に従って保持するメソッド
+// ドメインが u のホストに合わないクッキーや、安全でない接続で受信した Secure のクッキーは無視する
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := canonicalHost(u)
+	now := j.now()
+	for _, cookie := range cookies {
+		if cookie.Secure && !isSecureOrigin(u) {
+			continue
+		}
+		domain, hostOnly, ok := cookieDomain(host, cookie.Domain)
+		if !ok {
+			continue
+		}
+		e := &cookieEntry{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   domain,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			HostOnly: hostOnly,
+		}
+		if !strings.HasPrefix(e.Path, "/") {
+			e.Path = defaultCookiePath(u.Path)
+		}
+		switch {
+		case cookie.MaxAge < 0:
+			delete(j.entries, e.key())
+			continue
+		case cookie.MaxAge > 0:
+			expires := now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			e.Expires = &expires
+		case !cookie.Expires.IsZero():
+			expires := cookie.Expires
+			e.Expires = &expires
+		}
+		if e.expired(now) {
+			delete(j.entries, e.key())
+			continue
+		}
+		j.put(e)
+	}
+}
+
+// u へ送信するクッキーを、パスが長い順・保存した順に返すメソッド
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := canonicalHost(u)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	now := j.now()
+	matched := make([]*cookieEntry, 0)
+	for key, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, key)
+			continue
+		}
+		if e.Secure && !isSecureOrigin(u) {
+			continue
+		}
+		if !domainMatch(host, e.Domain, e.HostOnly) || !pathMatch(path, e.Path) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].Path) != len(matched[b].Path) {
+			return len(matched[a].Path) > len(matched[b].Path)
+		}
+		return matched[a].seq < matched[b].seq
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matched))
+	for _, e := range matched {
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return cookies
+}
+
+// クッキーを保持するメソッド
+// 同じキーのクッキーがある場合は、保存した順序を保ったまま置き換える
+func (j *cookieJar) put(e *cookieEntry) {
+	if old, ok := j.entries[e.key()]; ok {
+		e.seq = old.seq
+	} else {
+		j.seq++
+		e.seq = j.seq
+	}
+	j.entries[e.key()] = e
+}
+
+// クッキーファイルを読み込むメソッド
+// 先頭が "[" の場合は JSON、それ以外は Netscape 形式とする。ファイルが存在しない場合は何もしない
+func (j *cookieJar) load(path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []*cookieEntry
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		err = json.Unmarshal(b, &entries)
+	} else {
+		entries, err = parseNetscapeCookies(bytes.NewReader(b))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid cookie file '%s': %w", path, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for _, e := range entries {
+		e.Domain = strings.ToLower(strings.TrimPrefix(e.Domain, "."))
+		if e.Path == "" {
+			e.Path = "/"
+		}
+		if !e.expired(now) {
+			j.put(e)
+		}
+	}
+	return nil
+}
+
+// 保持しているクッキーをファイルへ保存するメソッド
+// path が "-" の場合は標準出力へ出力する
+func (j *cookieJar) save() error {
+	j.mu.Lock()
+	now := j.now()
+	entries := make([]*cookieEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		if !e.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	j.mu.Unlock()
+	sort.Slice(entries, func(a, b int) bool { return entries[a].seq < entries[b].seq })
+
+	var buf bytes.Buffer
+	if strings.HasSuffix(strings.ToLower(j.path), ".json") {
+		if err := writeJSON(&buf, entries); err != nil {
+			return err
+		}
+	} else {
+		writeNetscapeCookies(&buf, entries)
+	}
+	if j.path == "-" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(j.path, buf.Bytes(), 0o600)
+}
+
+// Netscape 形式のクッキーファイルを解析する関数
+// 各行は "domain, includeSubdomains, path, secure, expires, name, value" をタブで区切ったもの
+func parseNetscapeCookies(r io.Reader) ([]*cookieEntry, error) {
+	entries := make([]*cookieEntry, 0)
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 6 {
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields", n)
+		}
+		e := &cookieEntry{
+			Domain:   fields[0],
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry '%s'", n, fields[4])
+		}
+		if expires > 0 {
+			t := time.Unix(expires, 0)
+			e.Expires = &t
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// クッキーを Netscape 形式で w へ出力する関数
+// セッションクッキーの有効期限は 0 とする
+func writeNetscapeCookies(w io.Writer, entries []*cookieEntry) {
+	io.WriteString(w, netscapeHeader)
+	for _, e := range entries {
+		domain, includeSubdomains := e.Domain, "FALSE"
+		if !e.HostOnly {
+			domain, includeSubdomains = "."+e.Domain, "TRUE"
+		}
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if e.Expires != nil {
+			expires = e.Expires.Unix()
+		}
+		secure := "FALSE"
+		if e.Secure {
+			secure = "TRUE"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, includeSubdomains, e.Path, secure, expires, e.Name, e.Value)
+	}
+}
+
+// Domain 属性から保持するドメインと、ホストにのみ送信するかを返す関数
+// 属性がホストに合わない場合やパブリックサフィックス（"com", "co.uk" など）の場合は ok を false とする
+// ただし、パブリックサフィックスがホストと一致する場合はホストにのみ送信するクッキーとする
+func cookieDomain(host, attr string) (domain string, hostOnly, ok bool) {
+	attr = strings.ToLower(strings.TrimPrefix(attr, "."))
+	if attr == "" {
+		return host, true, true
+	}
+	if ps, _ := publicsuffix.PublicSuffix(attr); ps == attr {
+		if attr != host {
+			return "", false, false
+		}
+		return host, true, true
+	}
+	if attr == host {
+		return host, net.ParseIP(host) != nil, true
+	}
+	if net.ParseIP(host) != nil || !strings.HasSuffix(host, "."+attr) {
+		return "", false, false
+	}
+	return attr, false, true
+}
+
+// ホストがクッキーのドメインに合うかを返す関数
+func domainMatch(host, domain string, hostOnly bool) bool {
+	if host == domain {
+		return true
+	}
+	return !hostOnly && strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil
+}
+
+// リクエストのパスがクッキーのパスに合うかを返す関数
+func pathMatch(path, cookiePath string) bool {
+	if path == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+// Path 属性がない場合のパス（リクエストのパスの最後の "/" より前）を返す関数
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// クッキーのドメインと比較するための小文字のホスト名を返す関数
+func canonicalHost(u *url.URL) string {
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
+
+// Secure のクッキーを送受信できる接続かを返す関数
+// ブラウザと同じく、localhost とループバックアドレスは http でも安全とみなす
+func isSecureOrigin(u *url.URL) bool {
+	if u.Scheme == "https" {
+		return true
+	}
+	host := canonicalHost(u)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,372 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// テスト用の時刻で動作する cookieJar を生成する関数
+func newTestCookieJar(now time.Time) *cookieJar {
+	return &cookieJar{entries: make(map[string]*cookieEntry), now: func() time.Time { return now }}
+}
+
+// クッキーを "NAME=VALUE" の形式で "; " 区切りにした文字列を返す関数
+func cookieString(cookies []*http.Cookie) string {
+	s := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		s = append(s, c.String())
+	}
+	return strings.Join(s, "; ")
+}
+
+func TestCookieJar(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		setURL  string
+		cookies []*http.Cookie
+		getURL  string
+		want    string
+	}{
+		{
+			name:    "Host only",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1"}},
+			getURL:  "http://example.com/path",
+			want:    "a=1",
+		},
+		{
+			name:    "Host only is not sent to subdomains",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1"}},
+			getURL:  "http://www.example.com/",
+			want:    "",
+		},
+		{
+			name:    "Domain attribute is sent to subdomains",
+			setURL:  "http://www.example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: ".example.com"}},
+			getURL:  "http://api.example.com/",
+			want:    "a=1",
+		},
+		{
+			name:    "Domain attribute of another site is rejected",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "example.org"}},
+			getURL:  "http://example.org/",
+			want:    "",
+		},
+		{
+			name:    "Top-level domain is rejected",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "com"}},
+			getURL:  "http://other.com/",
+			want:    "",
+		},
+		{
+			name:    "Public suffix is rejected",
+			setURL:  "http://www.example.co.uk/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "co.uk"}},
+			getURL:  "http://other.co.uk/",
+			want:    "",
+		},
+		{
+			name:    "Domain attribute under a public suffix",
+			setURL:  "http://www.example.co.uk/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "example.co.uk"}},
+			getURL:  "http://api.example.co.uk/",
+			want:    "a=1",
+		},
+		{
+			name:    "Public suffix equal to the host is host only",
+			setURL:  "http://github.io/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "github.io"}},
+			getURL:  "http://example.github.io/",
+			want:    "",
+		},
+		{
+			name:    "Path attribute",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Path: "/api"}},
+			getURL:  "http://example.com/api/users",
+			want:    "a=1",
+		},
+		{
+			name:    "Path attribute does not match a sibling path",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Path: "/api"}},
+			getURL:  "http://example.com/apiv2",
+			want:    "",
+		},
+		{
+			name:    "Default path",
+			setURL:  "http://example.com/account/login",
+			cookies: []*http.Cookie{{Name: "a", Value: "1"}},
+			getURL:  "http://example.com/",
+			want:    "",
+		},
+		{
+			name:    "Longer paths first",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Path: "/"}, {Name: "b", Value: "2", Path: "/api"}},
+			getURL:  "http://example.com/api/users",
+			want:    "b=2; a=1",
+		},
+		{
+			name:    "Expired by Expires",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Expires: now.Add(-time.Hour)}},
+			getURL:  "http://example.com/",
+			want:    "",
+		},
+		{
+			name:    "Not yet expired",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Expires: now.Add(time.Hour)}},
+			getURL:  "http://example.com/",
+			want:    "a=1",
+		},
+		{
+			name:    "Deleted by Max-Age",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1"}, {Name: "a", Value: "", MaxAge: -1}},
+			getURL:  "http://example.com/",
+			want:    "",
+		},
+		{
+			name:    "Secure is not sent over http",
+			setURL:  "https://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			getURL:  "http://example.com/",
+			want:    "",
+		},
+		{
+			name:    "Secure is sent over https",
+			setURL:  "https://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			getURL:  "https://example.com/",
+			want:    "a=1",
+		},
+		{
+			name:    "Secure is not set over http",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			getURL:  "https://example.com/",
+			want:    "",
+		},
+		{
+			name:    "Secure over http on localhost",
+			setURL:  "http://localhost:8080/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1", Secure: true}},
+			getURL:  "http://localhost:8080/",
+			want:    "a=1",
+		},
+		{
+			name:    "Replaced",
+			setURL:  "http://example.com/",
+			cookies: []*http.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}, {Name: "a", Value: "3"}},
+			getURL:  "http://example.com/",
+			want:    "a=3; b=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := newTestCookieJar(now)
+			setURL, _ := url.Parse(tt.setURL)
+			getURL, _ := url.Parse(tt.getURL)
+
+			j.SetCookies(setURL, tt.cookies)
+			assert.Equal(t, tt.want, cookieString(j.Cookies(getURL)))
+		})
+	}
+}
+
+func TestCookieJar_SaveAndLoad(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	setURL, _ := url.Parse("https://www.example.com/")
+	cookies := []*http.Cookie{
+		{Name: "session", Value: "abc", HttpOnly: true},
+		{Name: "theme", Value: "dark", Domain: "example.com", Path: "/", Expires: now.Add(time.Hour), Secure: true},
+		{Name: "old", Value: "x", MaxAge: 1},
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{
+			name: "Netscape",
+			file: "cookies.txt",
+			want: netscapeHeader +
+				"#HttpOnly_www.example.com\tFALSE\t/\tFALSE\t0\tsession\tabc\n" +
+				".example.com\tTRUE\t/\tTRUE\t1704070800\ttheme\tdark\n",
+		},
+		{
+			name: "JSON",
+			file: "cookies.json",
+			want: `[
+  {
+    "name": "session",
+    "value": "abc",
+    "domain": "www.example.com",
+    "path": "/",
+    "secure": false,
+    "http_only": true,
+    "host_only": true
+  },
+  {
+    "name": "theme",
+    "value": "dark",
+    "domain": "example.com",
+    "path": "/",
+    "expires": "2024-01-01T01:00:00Z",
+    "secure": true,
+    "http_only": false,
+    "host_only": false
+  }
+]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			j := newTestCookieJar(now)
+			j.path = path
+			j.SetCookies(setURL, cookies)
+			// Max-Age が 1 秒のクッキーは保存する時点で期限切れ
+			j.now = func() time.Time { return now.Add(time.Second) }
+			if err := j.save(); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, string(b))
+
+			loaded := newTestCookieJar(now)
+			if err := loaded.load(path); err != nil {
+				t.Fatal(err)
+			}
+			getURL, _ := url.Parse("https://api.example.com/")
+			assert.Equal(t, "theme=dark", cookieString(loaded.Cookies(getURL)))
+			assert.Equal(t, "session=abc; theme=dark", cookieString(loaded.Cookies(setURL)))
+		})
+	}
+}
+
+func TestCookieJar_Load(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Netscape written by curl",
+			content: "# Netscape HTTP Cookie File\n\nexample.com\tFALSE\t/\tFALSE\t0\ta\t1\n.example.com\tTRUE\t/\tFALSE\t1\texpired\tx\n#HttpOnly_example.com\tFALSE\t/\tFALSE\t0\tb\t\n",
+			want:    "a=1; b=",
+		},
+		{
+			name:    "Invalid line",
+			content: "example.com\tFALSE\t/\n",
+			wantErr: "line 1: expected 7 tab-separated fields",
+		},
+		{
+			name:    "Invalid expiry",
+			content: "example.com\tFALSE\t/\tFALSE\tnever\ta\t1\n",
+			wantErr: "line 1: invalid expiry 'never'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cookies.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			j := newTestCookieJar(now)
+			err := j.load(path)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, "invalid cookie file '"+path+"': "+tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, _ := url.Parse("http://example.com/")
+			assert.Equal(t, tt.want, cookieString(j.Cookies(u)))
+		})
+	}
+}
+
+func TestWithCookies(t *testing.T) {
+	var got []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Cookie"))
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+			http.Redirect(w, r, "/me", http.StatusFound)
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1})
+		}
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	jar := filepath.Join(dir, "cookies.txt")
+	request := func(path string, opts ...Option) {
+		t.Helper()
+		c, err := NewHttpClient(ts.URL+path, http.MethodGet, "", []string{}, append(opts, WithFollowRedirects(DefaultMaxRedirects))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex, err := c.Do()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex.Close()
+		if err := c.SaveCookieJar(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request("/login", WithCookies([]string{"lang=ja; theme=dark", jar}), WithCookieJar(jar))
+	request("/users", WithCookies([]string{jar}), WithCookieJar(jar))
+	request("/logout", WithCookies([]string{jar}), WithCookieJar(jar))
+	request("/users", WithCookies([]string{jar}))
+
+	assert.Equal(t, []string{
+		"lang=ja; theme=dark",
+		"lang=ja; theme=dark; session=abc",
+		"session=abc",
+		"session=abc",
+		"",
+	}, got)
+
+	b, err := os.ReadFile(jar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, netscapeHeader, string(b))
+}
+
+func TestWithCookies_Invalid(t *testing.T) {
+	_, err := NewHttpClient("http://example.com", http.MethodGet, "", []string{}, WithCookies([]string{"a=1; =2"}))
+	assert.ErrorContains(t, err, "invalid cookie 'a=1; =2'")
+}
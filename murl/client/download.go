@@ -0,0 +1,150 @@
+package client
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// 再開しようとしたファイルが既に全てダウンロード済みであることを表すエラー
+var ErrAlreadyComplete = errors.New("the file is already completely downloaded")
+
+// --compressed で要求する Content-Encoding
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// ダウンロードを offset バイト目から再開するために Range ヘッダを付ける Option
+// サーバーが 206 以外を返した場合、Do はエラーを返す
+func WithResume(offset int64) Option {
+	return func(c *HttpClient) error {
+		if offset < 0 {
+			return fmt.Errorf("invalid resume offset: %d", offset)
+		}
+		if offset == 0 {
+			return nil
+		}
+		c.resumeOffset = offset
+		c.requestHeader["Range"] = []string{fmt.Sprintf("bytes=%d-", offset)}
+		return nil
+	}
+}
+
+// 圧縮したレスポンスを要求し、受信したボディを展開する Option
+// Accept-Encoding がユーザーによって指定されている場合はその値を送信する
+func WithCompressed(compressed bool) Option {
+	return func(c *HttpClient) error {
+		if !compressed {
+			return nil
+		}
+		c.compressed = true
+		c.setDefaultHeader("Accept-Encoding", acceptEncoding)
+		return nil
+	}
+}
+
+// ダウンロードを再開したレスポンスかを検証する関数
+// 全てダウンロード済みの場合は ErrAlreadyComplete を返す
+func checkResume(res *http.Response, offset int64) error {
+	cr := res.Header.Get("Content-Range")
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(cr); !ok || start != offset {
+			return fmt.Errorf("cannot resume from byte %d: unexpected Content-Range '%s'", offset, cr)
+		}
+		return nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		if size, ok := strings.CutPrefix(cr, "bytes */"); ok && size == strconv.FormatInt(offset, 10) {
+			return ErrAlreadyComplete
+		}
+	case http.StatusOK:
+		return errors.New("the server does not support byte ranges; cannot resume")
+	}
+	return fmt.Errorf("cannot resume from byte %d: %s", offset, res.Status)
+}
+
+// "bytes START-END/SIZE" の形式の Content-Range から START を返す関数
+func contentRangeStart(cr string) (int64, bool) {
+	r, ok := strings.CutPrefix(cr, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// Content-Encoding に従ってボディを展開する io.ReadCloser を返す関数
+// 複数の符号化が適用されている場合は逆順に展開し、未対応の符号化以降はそのまま返す
+func decodeBody(body io.ReadCloser, contentEncoding string) io.ReadCloser {
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		newReader := decoder(strings.ToLower(strings.TrimSpace(encodings[i])))
+		if newReader == nil {
+			break
+		}
+		body = &decodingReader{src: body, newReader: newReader}
+	}
+	return body
+}
+
+// 符号化の名前に対応する展開用の io.Reader を生成する関数を返す関数
+// identity や未対応の符号化の場合は nil を返す
+func decoder(encoding string) func(io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+	case "deflate":
+		return func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }
+	case "br":
+		return func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }
+	case "zstd":
+		return func(r io.Reader) (io.Reader, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		}
+	}
+	return nil
+}
+
+// 最初に読み込む時に展開用の io.Reader を生成する io.ReadCloser
+// HEAD や 204 など、ボディが空のレスポンスでヘッダの読み込みに失敗しないようにする
+type decodingReader struct {
+	src       io.ReadCloser
+	newReader func(io.Reader) (io.Reader, error)
+	r         io.Reader
+	err       error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		br := bufio.NewReader(d.src)
+		if _, d.err = br.Peek(1); d.err == nil {
+			d.r, d.err = d.newReader(br)
+		}
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *decodingReader) Close() error {
+	if c, ok := d.r.(io.Closer); ok {
+		c.Close()
+	}
+	return d.src.Close()
+}
@@ -0,0 +1,227 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+// 指定した符号化で圧縮した s を返す関数
+func compress(t *testing.T, encoding, s string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w = zw
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWithCompressed(t *testing.T) {
+	const content = `{"status":"ok"}`
+
+	tests := []struct {
+		name          string
+		encoding      string
+		customHeaders []string
+		wantAccept    string
+		wantBody      string
+	}{
+		{name: "gzip", encoding: "gzip", wantAccept: acceptEncoding, wantBody: content},
+		{name: "deflate", encoding: "deflate", wantAccept: acceptEncoding, wantBody: content},
+		{name: "br", encoding: "br", wantAccept: acceptEncoding, wantBody: content},
+		{name: "zstd", encoding: "zstd", wantAccept: acceptEncoding, wantBody: content},
+		{name: "Identity", encoding: "", wantAccept: acceptEncoding, wantBody: content},
+		{name: "Custom Accept-Encoding", encoding: "br", customHeaders: []string{"Accept-Encoding: br"}, wantAccept: "br", wantBody: content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotAccept string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAccept = r.Header.Get("Accept-Encoding")
+				if tt.encoding == "" {
+					w.Write([]byte(content))
+					return
+				}
+				w.Header().Set("Content-Encoding", tt.encoding)
+				w.Write(compress(t, tt.encoding, content))
+			}))
+			defer ts.Close()
+
+			c, err := NewHttpClient(ts.URL, http.MethodGet, "", tt.customHeaders, WithCompressed(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ex, err := c.Do()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ex.Close()
+
+			assert.Equal(t, tt.wantAccept, gotAccept)
+			assert.Equal(t, tt.wantBody, string(ex.Body()))
+			assert.Equal(t, tt.encoding, ex.Response.Header.Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestWithCompressed_Head(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+	}))
+	defer ts.Close()
+
+	c, err := NewHttpClient(ts.URL, http.MethodHead, "", []string{}, WithCompressed(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+
+	assert.Empty(t, ex.Body())
+}
+
+func TestWithResume(t *testing.T) {
+	const content = "0123456789"
+
+	tests := []struct {
+		name        string
+		offset      int64
+		handler     http.HandlerFunc
+		wantRange   string
+		wantResumed bool
+		wantBody    string
+		wantErr     string
+	}{
+		{
+			name:   "Partial content",
+			offset: 4,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			},
+			wantRange:   "bytes=4-",
+			wantResumed: true,
+			wantBody:    "456789",
+		},
+		{
+			name:   "No offset",
+			offset: 0,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			},
+			wantBody: content,
+		},
+		{
+			name:   "Already complete",
+			offset: 10,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			},
+			wantRange: "bytes=10-",
+			wantErr:   ErrAlreadyComplete.Error(),
+		},
+		{
+			name:   "Larger than the content",
+			offset: 20,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+			},
+			wantRange: "bytes=20-",
+			wantErr:   "cannot resume from byte 20: 416 Requested Range Not Satisfiable",
+		},
+		{
+			name:   "Ranges not supported",
+			offset: 4,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(content))
+			},
+			wantRange: "bytes=4-",
+			wantErr:   "the server does not support byte ranges; cannot resume",
+		},
+		{
+			name:   "Unexpected Content-Range",
+			offset: 4,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Range", "bytes 0-9/10")
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(content))
+			},
+			wantRange: "bytes=4-",
+			wantErr:   "cannot resume from byte 4: unexpected Content-Range 'bytes 0-9/10'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = r.Header.Get("Range")
+				tt.handler(w, r)
+			}))
+			defer ts.Close()
+
+			c, err := NewHttpClient(ts.URL, http.MethodGet, "", []string{}, WithResume(tt.offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ex, err := c.Do()
+			assert.Equal(t, tt.wantRange, gotRange)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ex.Close()
+
+			assert.Equal(t, tt.wantResumed, ex.Resumed)
+			assert.Equal(t, tt.wantBody, string(ex.Body()))
+		})
+	}
+}
+
+func TestExchange_Write_Stream(t *testing.T) {
+	ex := doTestRequest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0x01, 0x02})
+	}, func(ts *httptest.Server) string { return ts.URL }, "")
+
+	var buf bytes.Buffer
+	if err := ex.Write(&buf, FormatRaw, false); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []byte{0x00, 0x01, 0x02}, buf.Bytes())
+	assert.Nil(t, ex.body)
+	assert.Equal(t, int64(3), ex.size)
+}
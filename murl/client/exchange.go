@@ -0,0 +1,195 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// 送信したリクエストと受信したレスポンスの組
+type Exchange struct {
+	// BuildRequest で生成した最初のリクエスト
+	Request *http.Request
+	// 最後に受信したレスポンス（ボディは ResponseText などで読み込む）
+	Response *http.Response
+	// 追跡したリダイレクト
+	Hops []Hop
+	// 接続の各段階の時刻
+	Timing *Timing
+	// Range を指定してダウンロードを再開したか（ボディは続きの部分のみ）
+	Resumed bool
+
+	showTLS  bool
+	verbose  bool
+	renderer *bodyRenderer
+	// 読み込んだレスポンスボディのバイト数
+	size int64
+	// nil でない場合は読み込み済みのレスポンスボディ
+	body []byte
+	// 最後のリクエストで実際に送信したヘッダ
+	sentHeader http.Header
+}
+
+// リクエストを送信し、レスポンスのボディを読み込む前の Exchange を返すメソッド
+// 使い終わったら Close を呼び出す必要がある
+func (c *HttpClient) Do() (*Exchange, error) {
+	req, err := c.BuildRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	timing := &Timing{Start: time.Now()}
+	hops := make([]Hop, 0)
+	sent := &sentHeaders{}
+	hc := c.httpClient()
+	hc.CheckRedirect = c.checkRedirect(&hops, sent)
+
+	ctx := httptrace.WithClientTrace(req.Context(), timing.trace())
+	res, err := hc.Do(req.WithContext(httptrace.WithClientTrace(ctx, sent.trace())))
+	if err != nil {
+		return nil, err
+	}
+	if c.resumeOffset > 0 {
+		if err := checkResume(res, c.resumeOffset); err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+	}
+	if c.progress != nil {
+		res.Body = newProgressReader(res.Body, c.progress, c.resumeOffset, res.ContentLength)
+	}
+	if c.compressed {
+		res.Body = decodeBody(res.Body, res.Header.Get("Content-Encoding"))
+	}
+	res.Body = &countingReader{ReadCloser: res.Body}
+
+	renderer := c.renderer
+	if renderer == nil {
+		renderer = &bodyRenderer{}
+	}
+	ex := &Exchange{Request: req, Response: res, Hops: hops, Timing: timing, Resumed: c.resumeOffset > 0, showTLS: c.showTLS, verbose: c.verbose != nil, renderer: renderer, sentHeader: sent.last()}
+	return ex, nil
+}
+
+// リクエストを所定のフォーマットで返却
+func (e *Exchange) RequestText() string {
+	return CreateRequestText(e.Request)
+}
+
+// リダイレクトとレスポンスを所定のフォーマットで返却
+// verbose の場合はボディを読み込んだ後の各段階の所要時間も出力する
+func (e *Exchange) ResponseText() string {
+	sections := make([]string, 0)
+	if e.showTLS {
+		sections = append(sections, CreateTLSText(e.Response.TLS))
+	}
+	body, err := e.renderedBody(true)
+	if err != nil {
+		body = []byte(fmt.Sprintf("(%s)", err.Error()))
+	}
+	text := CreateRedirectText(e.Hops) + createResponseText(e.Response, body, sections...)
+
+	if e.verbose {
+		text += CreateTimingText(e.Timing)
+	}
+	return text
+}
+
+// レスポンスのボディを読み込んで返すメソッド
+// 2 回目以降は読み込み済みのボディを返す
+func (e *Exchange) Body() []byte {
+	if e.body == nil {
+		e.body = readBody(e.Response)
+		e.done()
+	}
+	return e.body
+}
+
+// レスポンスのボディを読み込みながら w へ書き込むメソッド
+// 読み込み済みの場合はそのボディを書き込む
+func (e *Exchange) stream(w io.Writer) error {
+	if e.body != nil {
+		_, err := w.Write(e.body)
+		return err
+	}
+	_, err := io.Copy(w, e.Response.Body)
+	e.done()
+	return err
+}
+
+// BodyOptions に従って表示用に加工したボディを返すメソッド
+// summarizeBinary が true の場合、バイナリのボディは概要または hexdump に置き換える
+func (e *Exchange) renderedBody(summarizeBinary bool) ([]byte, error) {
+	return e.renderer.render(e.Response.Header.Get("Content-Type"), e.Body(), summarizeBinary)
+}
+
+// レスポンスのボディを閉じるメソッド
+func (e *Exchange) Close() error {
+	e.done()
+	return e.Response.Body.Close()
+}
+
+// ボディを読み込み終えた時刻とサイズを記録するメソッド
+func (e *Exchange) done() {
+	if e.Timing.Done.IsZero() {
+		e.Timing.Done = time.Now()
+	}
+	if r, ok := e.Response.Body.(*countingReader); ok {
+		e.size = r.n
+	}
+}
+
+// 実際に送信したリクエストのヘッダを記録する構造体
+// Go が追加する Host や Accept-Encoding なども含み、再送などで複数回送信した場合は最後に送信したヘッダを保持する
+type sentHeaders struct {
+	mu     sync.Mutex
+	header http.Header
+	// 最後のリクエストのヘッダを送信し終えたか
+	done bool
+}
+
+// 送信したヘッダの行を記録する httptrace.ClientTrace を返すメソッド
+func (s *sentHeaders) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		WroteHeaderField: func(key string, values []string) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			// 送信し終えた後のヘッダは次のリクエストのものとして記録し直す
+			if s.header == nil || s.done {
+				s.header = make(http.Header)
+				s.done = false
+			}
+			s.header[key] = append(s.header[key], values...)
+		},
+		WroteHeaders: func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			s.done = true
+		},
+	}
+}
+
+// 最後に送信したリクエストのヘッダを返すメソッド
+func (s *sentHeaders) last() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.header
+}
+
+// 読み込んだバイト数を数える io.ReadCloser
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
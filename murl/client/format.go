@@ -0,0 +1,211 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// 出力形式
+const (
+	// ===Request=== / ===Response=== の形式のテキスト
+	FormatText = "text"
+	// リクエストとレスポンスをまとめた JSON
+	FormatJSON = "json"
+	// HTTP Archive (HAR) 1.2
+	FormatHAR = "har"
+	// レスポンスのボディのみ
+	FormatRaw = "raw"
+)
+
+// 対応している出力形式
+var Formats = []string{FormatText, FormatJSON, FormatHAR, FormatRaw}
+
+// リクエストとレスポンスを format の形式で w へ出力するメソッド
+// includeHeaders が true の場合、raw の形式ではボディの前にステータス行とヘッダを出力する
+// text, raw の形式では BodyOptions に従ってボディを加工する
+// raw の形式でボディを加工しない場合は、ボディをメモリに読み込まずに書き込む
+func (e *Exchange) Write(w io.Writer, format string, includeHeaders bool) error {
+	switch format {
+	case FormatText:
+		_, err := fmt.Fprintf(w, "%s\n%s\n", e.RequestText(), e.ResponseText())
+		return err
+	case FormatJSON:
+		return writeJSON(w, e.jsonObject())
+	case FormatHAR:
+		return writeJSON(w, e.har())
+	case FormatRaw:
+		if includeHeaders {
+			if _, err := io.WriteString(w, statusAndHeaderLines(e.Response)); err != nil {
+				return err
+			}
+		}
+		if e.renderer.passthrough() {
+			return e.stream(w)
+		}
+		body, err := e.renderedBody(false)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(body)
+		return err
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+// インデントした JSON を w へ出力する関数
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ステータス行とヘッダの行を、空行で終わるレスポンスの先頭部分の形式で返す関数
+func statusAndHeaderLines(res *http.Response) string {
+	s := fmt.Sprintf("%s %s\n", res.Proto, res.Status)
+	for _, key := range sortedKeys(res.Header) {
+		for _, v := range res.Header[key] {
+			s += fmt.Sprintf("%s: %s\n", key, v)
+		}
+	}
+	return s + "\n"
+}
+
+// JSON の形式で出力するリクエストとレスポンス
+type jsonExchange struct {
+	Request   jsonRequest  `json:"request"`
+	Redirects []Hop        `json:"redirects"`
+	Response  jsonResponse `json:"response"`
+	Timing    jsonTiming   `json:"timing"`
+}
+
+type jsonRequest struct {
+	URL     string              `json:"url"`
+	Method  string              `json:"method"`
+	Headers map[string][]string `json:"headers"`
+	Body    *string             `json:"body,omitempty"`
+}
+
+type jsonResponse struct {
+	Status  int                 `json:"status"`
+	Proto   string              `json:"proto"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+	// ボディが UTF-8 でない場合は "base64"
+	BodyEncoding string   `json:"body_encoding,omitempty"`
+	TLS          *jsonTLS `json:"tls,omitempty"`
+}
+
+type jsonTLS struct {
+	Version      string            `json:"version"`
+	Cipher       string            `json:"cipher"`
+	ALPN         string            `json:"alpn,omitempty"`
+	Certificates []jsonCertificate `json:"certificates"`
+}
+
+type jsonCertificate struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// 各段階の所要時間（ミリ秒）
+type jsonTiming struct {
+	DNSLookup       float64 `json:"dns_lookup_ms"`
+	TCPConnect      float64 `json:"tcp_connect_ms"`
+	TLSHandshake    float64 `json:"tls_handshake_ms"`
+	TimeToFirstByte float64 `json:"time_to_first_byte_ms"`
+	Total           float64 `json:"total_ms"`
+}
+
+// JSON の形式で出力する値を返すメソッド
+func (e *Exchange) jsonObject() jsonExchange {
+	res := e.Response
+	body, encoding := encodeBody(e.Body())
+	v := jsonExchange{
+		Request: jsonRequest{
+			URL:     e.Request.URL.String(),
+			Method:  e.Request.Method,
+			Headers: e.firstSentHeader(),
+			Body:    requestBody(e.Request),
+		},
+		Redirects: e.Hops,
+		Response: jsonResponse{
+			Status:       res.StatusCode,
+			Proto:        res.Proto,
+			URL:          res.Request.URL.String(),
+			Headers:      res.Header,
+			Body:         body,
+			BodyEncoding: encoding,
+		},
+		Timing: jsonTiming{
+			DNSLookup:       milliseconds(e.Timing.DNSLookup()),
+			TCPConnect:      milliseconds(e.Timing.Connect()),
+			TLSHandshake:    milliseconds(e.Timing.TLSHandshake()),
+			TimeToFirstByte: milliseconds(e.Timing.TimeToFirstByte()),
+			Total:           milliseconds(e.Timing.Total()),
+		},
+	}
+
+	if cs := res.TLS; cs != nil {
+		t := &jsonTLS{Version: tls.VersionName(cs.Version), Cipher: tls.CipherSuiteName(cs.CipherSuite), ALPN: cs.NegotiatedProtocol, Certificates: make([]jsonCertificate, 0)}
+		for _, cert := range cs.PeerCertificates {
+			t.Certificates = append(t.Certificates, jsonCertificate{Subject: cert.Subject.String(), Issuer: cert.Issuer.String(), NotAfter: cert.NotAfter.UTC()})
+		}
+		v.Response.TLS = t
+	}
+	return v
+}
+
+// 最初のリクエストで実際に送信したヘッダを返すメソッド
+// 送信したヘッダを記録できなかった場合は BuildRequest で生成したヘッダを返す
+func (e *Exchange) firstSentHeader() http.Header {
+	h := e.sentHeader
+	if len(e.Hops) > 0 {
+		h = e.Hops[0].RequestHeaders
+	}
+	if h == nil {
+		return e.Request.Header
+	}
+	return h
+}
+
+// リクエストボディを返す関数（ボディがない場合は nil）
+func requestBody(req *http.Request) *string {
+	if req.GetBody == nil {
+		return nil
+	}
+	r, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	b, err := io.ReadAll(r)
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
+
+// ボディを JSON の文字列として出力できる形式に変換する関数
+// UTF-8 でない場合は Base64 でエンコードし、"base64" を返す
+func encodeBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+// time.Duration をミリ秒に変換する関数
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// テスト用サーバへリクエストを送信し、ボディを読み込む前の Exchange を返す関数
+func doTestRequest(t *testing.T, handler http.HandlerFunc, rawurl func(ts *httptest.Server) string, data string) *Exchange {
+	t.Helper()
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	c, err := NewHttpClient(rawurl(ts), http.MethodPost, data, []string{"X-Custom: value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ex.Close() })
+	return ex
+}
+
+func TestExchange_Write(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header()["Date"] = nil
+		w.Write([]byte(`{"status":"ok"}`))
+	}
+	path := func(ts *httptest.Server) string { return ts.URL + "/path?b=2&a=1" }
+
+	tests := []struct {
+		name           string
+		format         string
+		includeHeaders bool
+		want           string
+	}{
+		{name: "Raw", format: FormatRaw, want: `{"status":"ok"}`},
+		{
+			name:           "Raw with headers",
+			format:         FormatRaw,
+			includeHeaders: true,
+			want:           "HTTP/1.1 200 OK\nContent-Length: 15\nContent-Type: application/json\n\n{\"status\":\"ok\"}",
+		},
+		{
+			name:   "Text",
+			format: FormatText,
+			want: `
+===Request===
+[URL] %URL%/path?b=2&a=1
+[Method] POST
+[Headers]
+  Content-Type: application/json
+  X-Custom: value
+
+
+===Response===
+[Status] 200
+[Protocol] HTTP/1.1
+[Headers]
+  Content-Length: 15
+  Content-Type: application/json
+[Body]
+{"status":"ok"}
+
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := doTestRequest(t, handler, path, `{"key":"value"}`)
+
+			var b bytes.Buffer
+			assert.NoError(t, ex.Write(&b, tt.format, tt.includeHeaders))
+			want := bytes.ReplaceAll([]byte(tt.want), []byte("%URL%"), []byte("http://"+ex.Request.URL.Host))
+			assert.Equal(t, string(want), b.String())
+		})
+	}
+
+	ex := doTestRequest(t, handler, path, "")
+	assert.Error(t, ex.Write(&bytes.Buffer{}, "xml", false))
+}
+
+func TestExchange_Write_JSON(t *testing.T) {
+	ex := doTestRequest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte{0xff, 0xfe})
+	}, func(ts *httptest.Server) string { return ts.URL }, `{"key":"value"}`)
+
+	var b bytes.Buffer
+	assert.NoError(t, ex.Write(&b, FormatJSON, false))
+
+	var got jsonExchange
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &got))
+	assert.Equal(t, http.MethodPost, got.Request.Method)
+	assert.Equal(t, []string{"value"}, got.Request.Headers["X-Custom"])
+	assert.Equal(t, `{"key":"value"}`, *got.Request.Body)
+	assert.Equal(t, []Hop{}, got.Redirects)
+	assert.Equal(t, http.StatusOK, got.Response.Status)
+	assert.Equal(t, "HTTP/1.1", got.Response.Proto)
+	// UTF-8 でないボディは Base64 でエンコードする
+	assert.Equal(t, "//4=", got.Response.Body)
+	assert.Equal(t, "base64", got.Response.BodyEncoding)
+	assert.Nil(t, got.Response.TLS)
+	assert.GreaterOrEqual(t, got.Timing.Total, got.Timing.TimeToFirstByte)
+}
+
+func TestExchange_Write_HAR(t *testing.T) {
+	ex := doTestRequest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	}, func(ts *httptest.Server) string { return ts.URL + "/path?b=2&a=1&a=3" }, `{"key":"value"}`)
+
+	var b bytes.Buffer
+	assert.NoError(t, ex.Write(&b, FormatHAR, false))
+
+	var got harLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &got))
+	assert.Equal(t, "1.2", got.Log.Version)
+	assert.Equal(t, "murl", got.Log.Creator.Name)
+	if !assert.Len(t, got.Log.Entries, 1) {
+		return
+	}
+
+	entry := got.Log.Entries[0]
+	assert.Equal(t, http.MethodPost, entry.Request.Method)
+	assert.Equal(t, []harNameValue{{Name: "a", Value: "1"}, {Name: "a", Value: "3"}, {Name: "b", Value: "2"}}, entry.Request.QueryString)
+	// Go が追加したヘッダも含めて、実際に送信したヘッダを出力する
+	wantHeaders := []harNameValue{
+		{Name: "Accept-Encoding", Value: "gzip"},
+		{Name: "Content-Length", Value: "15"},
+		{Name: "Content-Type", Value: "application/json"},
+		{Name: "Host", Value: ex.Request.URL.Host},
+		{Name: "User-Agent", Value: "Go-http-client/1.1"},
+		{Name: "X-Custom", Value: "value"},
+	}
+	assert.Equal(t, wantHeaders, entry.Request.Headers)
+	assert.Equal(t, &harPostData{MimeType: "application/json", Text: `{"key":"value"}`}, entry.Request.PostData)
+	assert.Equal(t, 15, entry.Request.BodySize)
+	assert.Equal(t, http.StatusOK, entry.Response.Status)
+	assert.Equal(t, "OK", entry.Response.StatusText)
+	assert.Equal(t, harContent{Size: 2, MimeType: "text/plain", Text: "ok"}, entry.Response.Content)
+	assert.Equal(t, "127.0.0.1", entry.ServerIPAddress)
+	// 平文の HTTP では TLS のハンドシェイクを行わない
+	assert.Equal(t, float64(-1), entry.Timings.SSL)
+	assert.Equal(t, float64(-1), entry.Timings.Blocked)
+}
+
+func TestExchange_Write_Redirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusFound))
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	c, err := NewHttpClient(ts.URL+"/a", http.MethodGet, "", []string{"X-Custom: value"}, WithFollowRedirects(DefaultMaxRedirects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ex.Close() })
+	host := ex.Request.URL.Host
+
+	var b bytes.Buffer
+	assert.NoError(t, ex.Write(&b, FormatHAR, false))
+	var har harLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &har))
+	// リダイレクトごとに 1 エントリを出力する
+	if assert.Len(t, har.Log.Entries, 2) {
+		first, last := har.Log.Entries[0], har.Log.Entries[1]
+		assert.Equal(t, ts.URL+"/a", first.Request.URL)
+		assert.Contains(t, first.Request.Headers, harNameValue{Name: "Host", Value: host})
+		assert.Contains(t, first.Request.Headers, harNameValue{Name: "X-Custom", Value: "value"})
+		assert.Equal(t, http.StatusFound, first.Response.Status)
+		assert.Equal(t, "/b", first.Response.RedirectURL)
+		assert.Equal(t, ts.URL+"/b", last.Request.URL)
+		// リダイレクト先へのリクエストには Referer などのヘッダも追加される
+		assert.Contains(t, last.Request.Headers, harNameValue{Name: "Referer", Value: ts.URL + "/a"})
+		assert.Equal(t, http.StatusOK, last.Response.Status)
+		assert.Equal(t, "ok", last.Response.Content.Text)
+	}
+
+	b.Reset()
+	assert.NoError(t, ex.Write(&b, FormatJSON, false))
+	var got jsonExchange
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &got))
+	assert.Equal(t, []string{host}, got.Request.Headers["Host"])
+	if assert.Len(t, got.Redirects, 1) {
+		hop := got.Redirects[0]
+		assert.Equal(t, http.MethodGet, hop.Method)
+		assert.Equal(t, []string{"gzip"}, hop.RequestHeaders["Accept-Encoding"])
+		assert.Equal(t, "HTTP/1.1", hop.Proto)
+		assert.Equal(t, []string{"/b"}, hop.Headers["Location"])
+	}
+}
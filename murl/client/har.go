@@ -0,0 +1,220 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// HTTP Archive (HAR) 1.2 のルート
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// 各段階の所要時間（ミリ秒）。該当しない段階は -1
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// 追跡したリダイレクトと最後のリクエストを 1 回ずつエントリとする HAR を返すメソッド
+// リクエストのヘッダには実際に送信したヘッダを出力する
+func (e *Exchange) har() harLog {
+	res, t := e.Response, e.Timing
+	entries := make([]harEntry, 0, len(e.Hops)+1)
+	// 各エントリはその前のレスポンスを受信した時刻から始まるものとする
+	start := t.Start
+	for _, hop := range e.Hops {
+		d := between(start, hop.received)
+		entries = append(entries, harEntry{
+			StartedDateTime: start.UTC().Format(time.RFC3339Nano),
+			Time:            milliseconds(d),
+			Request:         newHARRequest(hop.request, hop.Proto, hop.RequestHeaders),
+			Response: harResponse{
+				Status:      hop.Status,
+				StatusText:  http.StatusText(hop.Status),
+				HTTPVersion: hop.Proto,
+				Cookies:     []harNameValue{},
+				Headers:     harHeaders(hop.Headers),
+				Content:     harContent{MimeType: hop.Headers.Get("Content-Type")},
+				RedirectURL: hop.Location,
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			// リダイレクトのレスポンスは接続の各段階の時刻を記録しないため、全体を待ち時間とする
+			Timings: harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: milliseconds(d)},
+		})
+		start = hop.received
+	}
+
+	body, encoding := encodeBody(e.Body())
+	// HAR の connect は TLS のハンドシェイクを含む
+	connectEnd := t.ConnectDone
+	if !t.TLSDone.IsZero() {
+		connectEnd = t.TLSDone
+	}
+	sentHeader := e.sentHeader
+	if sentHeader == nil {
+		sentHeader = res.Request.Header
+	}
+
+	entry := harEntry{
+		StartedDateTime: start.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds(between(start, t.Done)),
+		Request:         newHARRequest(res.Request, res.Proto, sentHeader),
+		Response: harResponse{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: res.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(res.Header),
+			Content: harContent{
+				Size:     len(e.Body()),
+				MimeType: res.Header.Get("Content-Type"),
+				Text:     body,
+				Encoding: encoding,
+			},
+			RedirectURL: res.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(e.Body()),
+		},
+		Timings: harTimings{
+			Blocked: -1,
+			DNS:     harDuration(t.DNSLookup()),
+			Connect: harDuration(between(t.ConnectStart, connectEnd)),
+			SSL:     harDuration(t.TLSHandshake()),
+			Send:    milliseconds(between(t.GotConn, t.WroteRequest)),
+			Wait:    milliseconds(between(t.WroteRequest, t.FirstByte)),
+			Receive: milliseconds(between(t.FirstByte, t.Done)),
+		},
+	}
+	if host, _, err := net.SplitHostPort(t.RemoteAddr); err == nil {
+		entry.ServerIPAddress = host
+	}
+	entries = append(entries, entry)
+
+	var v harLog
+	v.Log.Version = "1.2"
+	v.Log.Creator = harCreator{Name: "murl", Version: version()}
+	v.Log.Entries = entries
+	return v
+}
+
+// リクエストを HAR のリクエストに変換する関数
+// header には実際に送信したヘッダを指定する
+func newHARRequest(req *http.Request, proto string, header http.Header) harRequest {
+	r := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	query := req.URL.Query()
+	for _, key := range sortedKeys(query) {
+		for _, v := range query[key] {
+			r.QueryString = append(r.QueryString, harNameValue{Name: key, Value: v})
+		}
+	}
+	if b := requestBody(req); b != nil {
+		r.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: *b}
+		r.BodySize = len(*b)
+	}
+	return r
+}
+
+// ヘッダを名前の昇順に並べた HAR の name/value のリストに変換する関数
+func harHeaders(header http.Header) []harNameValue {
+	list := make([]harNameValue, 0, len(header))
+	for _, key := range sortedKeys(header) {
+		for _, v := range header[key] {
+			list = append(list, harNameValue{Name: key, Value: v})
+		}
+	}
+	return list
+}
+
+// 該当しない段階（0）を -1 とし、ミリ秒に変換する関数
+func harDuration(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+	return milliseconds(d)
+}
+
+// ビルド時のモジュールのバージョンを返す関数
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// -H で指定されたヘッダ 1 つ分
+type header struct {
+	name  string
+	value string
+	// "Name:" の形式で値が空の場合は、そのヘッダを送信しない
+	remove bool
+}
+
+// "Name: value" の形式のヘッダを解析する関数（RFC 9110 準拠）
+// 最初の ":" で名前と値に分割し、前後の空白を取り除く
+// "Name:" は送信しないヘッダの指定、"Name;" は値が空のヘッダとして扱う
+func parseHeader(s string) (header, error) {
+	s = strings.TrimSpace(s)
+	if name, ok := strings.CutSuffix(s, ";"); ok && !strings.Contains(name, ":") {
+		return newHeader(s, name, "", false)
+	}
+
+	name, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return header{}, fmt.Errorf("invalid format header: %s", s)
+	}
+	value = strings.TrimSpace(value)
+	return newHeader(s, name, value, value == "")
+}
+
+// ヘッダ名と値を検証して header を生成する関数
+func newHeader(s, name, value string, remove bool) (header, error) {
+	name = strings.TrimSpace(name)
+	if !isToken(name) {
+		return header{}, fmt.Errorf("invalid header name: %s", s)
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return header{}, fmt.Errorf("invalid header value: %s", s)
+	}
+
+	return header{name: http.CanonicalHeaderKey(name), value: value, remove: remove}, nil
+}
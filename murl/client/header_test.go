@@ -0,0 +1,50 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		want      header
+		assertion assert.ErrorAssertionFunc
+	}{
+		{name: "Simple", s: "Connection: keep-alive", want: header{name: "Connection", value: "keep-alive"}, assertion: assert.NoError},
+		{name: "Colons in value", s: "Referer: https://example.com:8080", want: header{name: "Referer", value: "https://example.com:8080"}, assertion: assert.NoError},
+		{name: "Trim whitespace", s: "  x-time :\t12:30  ", want: header{name: "X-Time", value: "12:30"}, assertion: assert.NoError},
+		{name: "Empty value", s: "X-Empty;", want: header{name: "X-Empty", value: ""}, assertion: assert.NoError},
+		{name: "Semicolon in value", s: "Accept: text/html;", want: header{name: "Accept", value: "text/html;"}, assertion: assert.NoError},
+		{name: "Remove", s: "User-Agent:", want: header{name: "User-Agent", remove: true}, assertion: assert.NoError},
+		{name: "Empty", s: "", want: header{}, assertion: assert.Error},
+		{name: "No colon", s: "Connection keep-alive", want: header{}, assertion: assert.Error},
+		{name: "Invalid name", s: "X Header: value", want: header{}, assertion: assert.Error},
+		{name: "Empty name", s: ": value", want: header{}, assertion: assert.Error},
+		{name: "Invalid value", s: "X-Header: a\r\nX-Injected: b", want: header{}, assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeader(tt.s)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewHttpClient_Header(t *testing.T) {
+	c, err := NewHttpClient("https://example.com", "POST", `{"a":1}`, []string{"Content-Type:", "User-Agent:", "X-Empty;", "X-Time: 12:30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string][]string{"X-Empty": {""}, "X-Time": {"12:30"}}, c.requestHeader)
+
+	req, err := c.BuildRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{""}, req.Header["User-Agent"])
+	assert.Empty(t, req.Header.Get("Content-Type"))
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jq の ".key", ".[0]", ".[\"key\"]", ".[]" を組み合わせたパスの 1 段
+type jqStep struct {
+	// "key", "index", "iterate" のいずれか
+	kind  string
+	key   string
+	index int
+}
+
+// jq のパスの文法のうち、フィールド・添字・配列の展開のみに対応したパスを解析する関数
+// e.g.) ".", ".items[0].name", ".items[].id", ".[\"key with space\"]"
+func parseJQ(path string) ([]jqStep, error) {
+	invalid := func(msg string) error {
+		return fmt.Errorf("invalid jq path '%s': %s", path, msg)
+	}
+	if !strings.HasPrefix(path, ".") {
+		return nil, invalid("must start with '.'")
+	}
+
+	steps := make([]jqStep, 0)
+	s := path[1:]
+	first := true
+	for s != "" {
+		switch {
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, invalid("missing ']'")
+			}
+			inner := s[1:end]
+			switch {
+			case inner == "":
+				steps = append(steps, jqStep{kind: "iterate"})
+			case strings.HasPrefix(inner, `"`):
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, invalid("invalid string " + inner)
+				}
+				steps = append(steps, jqStep{kind: "key", key: key})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, invalid("invalid index " + inner)
+				}
+				steps = append(steps, jqStep{kind: "index", index: i})
+			}
+			s = s[end+1:]
+		case s[0] == '.' || first:
+			if s[0] == '.' {
+				if first {
+					return nil, invalid("unexpected '.'")
+				}
+				s = s[1:]
+			}
+			if strings.HasPrefix(s, "[") {
+				break
+			}
+			if strings.HasPrefix(s, `"`) {
+				end := strings.IndexByte(s[1:], '"')
+				if end == -1 {
+					return nil, invalid("missing '\"'")
+				}
+				steps = append(steps, jqStep{kind: "key", key: s[1 : end+1]})
+				s = s[end+2:]
+				break
+			}
+			n := 0
+			for n < len(s) && isIdentChar(s[n], n == 0) {
+				n++
+			}
+			if n == 0 {
+				return nil, invalid("missing field name")
+			}
+			steps = append(steps, jqStep{kind: "key", key: s[:n]})
+			s = s[n:]
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected '%c'", s[0]))
+		}
+		first = false
+	}
+	return steps, nil
+}
+
+// jq の識別子に使える文字かを返す関数
+func isIdentChar(c byte, first bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || !first && '0' <= c && c <= '9'
+}
+
+// JSON のボディにパスを適用し、一致した値を返す関数
+// 存在しないフィールドや範囲外の添字は jq と同じく null とする
+func extractJSON(body []byte, steps []jqStep) ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var root any
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %w", err)
+	}
+
+	values := []any{root}
+	for _, step := range steps {
+		next := make([]any, 0, len(values))
+		for _, v := range values {
+			switch step.kind {
+			case "key":
+				switch t := v.(type) {
+				case map[string]any:
+					next = append(next, t[step.key])
+				case nil:
+					next = append(next, nil)
+				default:
+					return nil, fmt.Errorf("cannot index %s with \"%s\"", jsonType(v), step.key)
+				}
+			case "index":
+				switch t := v.(type) {
+				case []any:
+					i := step.index
+					if i < 0 {
+						i += len(t)
+					}
+					if i < 0 || i >= len(t) {
+						next = append(next, nil)
+					} else {
+						next = append(next, t[i])
+					}
+				case nil:
+					next = append(next, nil)
+				default:
+					return nil, fmt.Errorf("cannot index %s with number", jsonType(v))
+				}
+			case "iterate":
+				switch t := v.(type) {
+				case []any:
+					next = append(next, t...)
+				case map[string]any:
+					keys := make([]string, 0, len(t))
+					for k := range t {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, t[k])
+					}
+				default:
+					return nil, fmt.Errorf("cannot iterate over %s", jsonType(v))
+				}
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
+
+// エラーメッセージに使う JSON の値の型名を返す関数
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJQ(t *testing.T) {
+	tests := []struct {
+		path      string
+		want      []jqStep
+		assertion assert.ErrorAssertionFunc
+	}{
+		{path: ".", want: []jqStep{}, assertion: assert.NoError},
+		{path: ".items[0].name", want: []jqStep{{kind: "key", key: "items"}, {kind: "index"}, {kind: "key", key: "name"}}, assertion: assert.NoError},
+		{path: ".[].id", want: []jqStep{{kind: "iterate"}, {kind: "key", key: "id"}}, assertion: assert.NoError},
+		{path: `.["a b"][-1]`, want: []jqStep{{kind: "key", key: "a b"}, {kind: "index", index: -1}}, assertion: assert.NoError},
+		{path: `."a-b".c`, want: []jqStep{{kind: "key", key: "a-b"}, {kind: "key", key: "c"}}, assertion: assert.NoError},
+		{path: "items", assertion: assert.Error},
+		{path: "..a", assertion: assert.Error},
+		{path: ".a.", assertion: assert.Error},
+		{path: ".a[0", assertion: assert.Error},
+		{path: ".a[x]", assertion: assert.Error},
+		{path: ".a b", assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseJQ(tt.path)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	body := []byte(`{"items":[{"id":1,"name":"a"},{"id":2.5,"name":"b"}],"meta":{"total":2}}`)
+
+	tests := []struct {
+		path    string
+		want    []any
+		wantErr bool
+	}{
+		{path: ".meta.total", want: []any{json.Number("2")}},
+		{path: ".items[-1].name", want: []any{"b"}},
+		{path: ".items[].id", want: []any{json.Number("1"), json.Number("2.5")}},
+		{path: ".meta[]", want: []any{json.Number("2")}},
+		{path: ".missing.field", want: []any{nil}},
+		{path: ".items[5]", want: []any{nil}},
+		{path: ".items.name", wantErr: true},
+		{path: ".meta[0]", wantErr: true},
+		{path: ".meta.total[]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			steps, err := parseJQ(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractJSON(body, steps)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := extractJSON([]byte("<html>"), nil)
+	assert.ErrorContains(t, err, "body is not JSON")
+}
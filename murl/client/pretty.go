@@ -0,0 +1,241 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// 色付けに使う ANSI エスケープシーケンス
+const (
+	colorReset   = "\x1b[0m"
+	colorKey     = "\x1b[34;1m"
+	colorString  = "\x1b[32m"
+	colorNumber  = "\x1b[36m"
+	colorLiteral = "\x1b[35m"
+	colorTag     = "\x1b[34m"
+	colorAttr    = "\x1b[36m"
+	colorComment = "\x1b[90m"
+)
+
+// 終了タグを持たない HTML の要素
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// JSON をインデントした結果を返す関数
+// JSON として不正な場合は元の値を返す
+func prettyJSON(b []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(b), "", "  "); err != nil {
+		return b
+	}
+	return buf.Bytes()
+}
+
+// JSON のキー・文字列・数値・リテラルを色付けした結果を返す関数
+func colorJSON(b []byte) []byte {
+	var out bytes.Buffer
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(b) && b[j] != '"' {
+				if b[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(b))
+
+			// 後ろに ":" が続く文字列はキーとする
+			k := j
+			for k < len(b) && (b[k] == ' ' || b[k] == '\t') {
+				k++
+			}
+			color := colorString
+			if k < len(b) && b[k] == ':' {
+				color = colorKey
+			}
+			out.WriteString(color)
+			out.Write(b[i:j])
+			out.WriteString(colorReset)
+			i = j
+		case c == '-' || '0' <= c && c <= '9':
+			j := i + 1
+			for j < len(b) && strings.IndexByte("0123456789.eE+-", b[j]) != -1 {
+				j++
+			}
+			out.WriteString(colorNumber)
+			out.Write(b[i:j])
+			out.WriteString(colorReset)
+			i = j
+		case c == 't' || c == 'f' || c == 'n':
+			j := i + 1
+			for j < len(b) && 'a' <= b[j] && b[j] <= 'z' {
+				j++
+			}
+			out.WriteString(colorLiteral)
+			out.Write(b[i:j])
+			out.WriteString(colorReset)
+			i = j
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.Bytes()
+}
+
+// XML・HTML を 1 要素 1 行にインデントした結果を返す関数
+// 解析できない場合は元の値を返す
+func prettyMarkup(b []byte, html, color bool) []byte {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	if html {
+		dec.Strict = false
+		dec.Entity = xml.HTMLEntity
+	}
+
+	tokens := make([]xml.Token, 0)
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return b
+		}
+		if cd, ok := tok.(xml.CharData); ok && len(bytes.TrimSpace(cd)) == 0 {
+			continue
+		}
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+
+	p := &markupPrinter{color: color}
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			name := markupName(t.Name)
+			p.indent(depth)
+			p.startTag(t)
+			if html && htmlVoidElements[strings.ToLower(name)] {
+				p.newline()
+				continue
+			}
+
+			// 子要素を持たない要素は 1 行で出力する
+			if i+1 < len(tokens) {
+				if end, ok := tokens[i+1].(xml.EndElement); ok && markupName(end.Name) == name {
+					p.endTag(end)
+					p.newline()
+					i++
+					continue
+				}
+			}
+			if i+2 < len(tokens) {
+				cd, isText := tokens[i+1].(xml.CharData)
+				end, isEnd := tokens[i+2].(xml.EndElement)
+				if isText && isEnd && markupName(end.Name) == name {
+					p.text(cd)
+					p.endTag(end)
+					p.newline()
+					i += 2
+					continue
+				}
+			}
+			p.newline()
+			depth++
+		case xml.EndElement:
+			if html && htmlVoidElements[strings.ToLower(markupName(t.Name))] {
+				continue
+			}
+			depth = max(depth-1, 0)
+			p.indent(depth)
+			p.endTag(t)
+			p.newline()
+		case xml.CharData:
+			p.indent(depth)
+			p.text(t)
+			p.newline()
+		case xml.Comment:
+			p.indent(depth)
+			p.colored(colorComment, "<!--"+string(t)+"-->")
+			p.newline()
+		case xml.ProcInst:
+			p.indent(depth)
+			p.colored(colorComment, "<?"+t.Target+" "+string(t.Inst)+"?>")
+			p.newline()
+		case xml.Directive:
+			p.indent(depth)
+			p.colored(colorComment, "<!"+string(t)+">")
+			p.newline()
+		}
+	}
+	return bytes.TrimSuffix(p.buf.Bytes(), []byte("\n"))
+}
+
+// 名前空間の接頭辞を含めた要素名・属性名を返す関数
+func markupName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// XML・HTML のトークンを出力するためのバッファ
+type markupPrinter struct {
+	buf   bytes.Buffer
+	color bool
+}
+
+func (p *markupPrinter) indent(depth int) {
+	p.buf.WriteString(strings.Repeat("  ", depth))
+}
+
+func (p *markupPrinter) newline() {
+	p.buf.WriteByte('\n')
+}
+
+func (p *markupPrinter) colored(color, s string) {
+	if p.color {
+		p.buf.WriteString(color + s + colorReset)
+		return
+	}
+	p.buf.WriteString(s)
+}
+
+func (p *markupPrinter) startTag(t xml.StartElement) {
+	p.buf.WriteString("<")
+	p.colored(colorTag, markupName(t.Name))
+	for _, a := range t.Attr {
+		p.buf.WriteString(" ")
+		p.colored(colorAttr, markupName(a.Name))
+		p.buf.WriteString("=")
+		p.colored(colorString, `"`+escapeMarkup(a.Value, true)+`"`)
+	}
+	p.buf.WriteString(">")
+}
+
+func (p *markupPrinter) endTag(t xml.EndElement) {
+	p.buf.WriteString("</")
+	p.colored(colorTag, markupName(t.Name))
+	p.buf.WriteString(">")
+}
+
+func (p *markupPrinter) text(cd xml.CharData) {
+	p.buf.WriteString(escapeMarkup(string(bytes.TrimSpace(cd)), false))
+}
+
+// テキストや属性値に含められない文字をエスケープする関数
+func escapeMarkup(s string, attr bool) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	if attr {
+		r = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	}
+	return r.Replace(s)
+}
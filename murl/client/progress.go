@@ -0,0 +1,124 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 進捗表示を更新する間隔
+const progressInterval = 200 * time.Millisecond
+
+// 進捗バーの幅
+const progressBarWidth = 30
+
+// レスポンスのボディを受信する進捗を w に出力する Option
+// 転送速度と、Content-Length がある場合は割合と残り時間を出力する
+func WithProgress(w io.Writer) Option {
+	return func(c *HttpClient) error {
+		c.progress = w
+		return nil
+	}
+}
+
+// 読み込んだバイト数から進捗を出力する io.ReadCloser
+type progressReader struct {
+	io.ReadCloser
+	w io.Writer
+	// 再開したダウンロードで既に受信済みのバイト数
+	offset int64
+	// ボディのバイト数（不明な場合は負）
+	total int64
+	n     int64
+	now   func() time.Time
+	start time.Time
+	last  time.Time
+	ended bool
+}
+
+// 進捗を出力する progressReader を生成する関数
+func newProgressReader(body io.ReadCloser, w io.Writer, offset, contentLength int64) *progressReader {
+	total := int64(-1)
+	if contentLength >= 0 {
+		total = offset + contentLength
+	}
+	now := time.Now()
+	return &progressReader{ReadCloser: body, w: w, offset: offset, total: total, now: time.Now, start: now, last: now}
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	if err != nil {
+		r.finish()
+	} else if now := r.now(); now.Sub(r.last) >= progressInterval {
+		r.last = now
+		fmt.Fprintf(r.w, "\r%s", r.line(now))
+	}
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	r.finish()
+	return r.ReadCloser.Close()
+}
+
+// 最終的な進捗を出力して改行するメソッド
+func (r *progressReader) finish() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	fmt.Fprintf(r.w, "\r%s\n", r.line(r.now()))
+}
+
+// 現在の進捗の行を返すメソッド
+func (r *progressReader) line(now time.Time) string {
+	elapsed := now.Sub(r.start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(r.n) / elapsed.Seconds()
+	}
+	received := r.offset + r.n
+	if r.total <= 0 {
+		return fmt.Sprintf("%10s  %10s/s", formatBytes(received), formatBytes(int64(rate)))
+	}
+
+	ratio := min(float64(received)/float64(r.total), 1)
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	eta := "--:--"
+	if rate > 0 {
+		eta = formatETA(time.Duration(float64(r.total-received) / rate * float64(time.Second)))
+	}
+	return fmt.Sprintf("%5.1f%% [%s] %10s / %-10s %10s/s  ETA %s", ratio*100, bar, formatBytes(received), formatBytes(r.total), formatBytes(int64(rate)), eta)
+}
+
+// バイト数を 2 進接頭辞の単位で返す関数
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	for _, prefix := range "KMGTP" {
+		v /= unit
+		if v < unit || prefix == 'P' {
+			return fmt.Sprintf("%.1f %ciB", v, prefix)
+		}
+	}
+	return ""
+}
+
+// 残り時間を "mm:ss" または "h:mm:ss" の形式で返す関数
+func formatETA(d time.Duration) string {
+	s := int64(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%02d:%02d", s/60, s%60)
+}
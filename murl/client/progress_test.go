@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressReader_Line(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		offset  int64
+		total   int64
+		n       int64
+		elapsed time.Duration
+		want    string
+	}{
+		{
+			name:    "Known length",
+			total:   4 * 1024 * 1024,
+			n:       1024 * 1024,
+			elapsed: 2 * time.Second,
+			want:    " 25.0% [=======>                      ]    1.0 MiB / 4.0 MiB     512.0 KiB/s  ETA 00:06",
+		},
+		{
+			name:    "Resumed",
+			offset:  3 * 1024 * 1024,
+			total:   4 * 1024 * 1024,
+			n:       512 * 1024,
+			elapsed: time.Second,
+			want:    " 87.5% [==========================>   ]    3.5 MiB / 4.0 MiB     512.0 KiB/s  ETA 00:01",
+		},
+		{
+			name:    "Completed",
+			total:   100,
+			n:       100,
+			elapsed: time.Second,
+			want:    "100.0% [==============================]      100 B / 100 B           100 B/s  ETA 00:00",
+		},
+		{
+			name:    "Nothing received",
+			total:   100,
+			elapsed: 0,
+			want:    "  0.0% [>                             ]        0 B / 100 B             0 B/s  ETA --:--",
+		},
+		{
+			name:    "Unknown length",
+			total:   -1,
+			n:       2048,
+			elapsed: time.Second,
+			want:    "   2.0 KiB     2.0 KiB/s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &progressReader{offset: tt.offset, total: tt.total, n: tt.n, start: start}
+			assert.Equal(t, tt.want, r.line(start.Add(tt.elapsed)))
+		})
+	}
+}
+
+func TestProgressReader_Read(t *testing.T) {
+	var out bytes.Buffer
+	r := newProgressReader(io.NopCloser(strings.NewReader("0123456789")), &out, 0, 10)
+	now := r.start
+	r.now = func() time.Time {
+		now = now.Add(progressInterval)
+		return now
+	}
+
+	buf := make([]byte, 4)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			break
+		}
+	}
+	r.Close()
+
+	lines := strings.Split(out.String(), "\r")
+	assert.Equal(t, "", lines[0])
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[1], " 40.0%"), lines[1])
+	assert.True(t, strings.HasPrefix(lines[4], "100.0%"), lines[4])
+	assert.True(t, strings.HasSuffix(out.String(), "\n"))
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 5 * 1024 * 1024 * 1024, want: "5.0 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatBytes(tt.n))
+		})
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "00:05", formatETA(5*time.Second))
+	assert.Equal(t, "01:40", formatETA(100*time.Second))
+	assert.Equal(t, "1:01:01", formatETA(3661*time.Second))
+}
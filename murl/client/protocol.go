@@ -0,0 +1,44 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 使用する HTTP のバージョン
+const (
+	// HTTP/1.1 のみ
+	HTTP11 = "1.1"
+	// https の場合は ALPN で HTTP/2 を優先し、http の場合は HTTP/1.1
+	HTTP2 = "2"
+	// 事前に HTTP/2 に対応していることが分かっているサーバへ、http の場合も h2c（平文の HTTP/2）で送信する
+	HTTP2PriorKnowledge = "2-prior-knowledge"
+)
+
+// 対応している HTTP のバージョン
+var HTTPVersions = []string{HTTP11, HTTP2, HTTP2PriorKnowledge}
+
+// 使用する HTTP のバージョンを指定する Option
+// 空の場合は https の場合に HTTP/2 を優先する Go のデフォルトの動作とする
+func WithHTTPVersion(version string) Option {
+	return func(c *HttpClient) error {
+		p := &http.Protocols{}
+		switch version {
+		case "":
+			return nil
+		case HTTP11:
+			p.SetHTTP1(true)
+		case HTTP2:
+			p.SetHTTP1(true)
+			p.SetHTTP2(true)
+		case HTTP2PriorKnowledge:
+			p.SetHTTP2(true)
+			p.SetUnencryptedHTTP2(true)
+		default:
+			return fmt.Errorf("unsupported HTTP version '%s' (available: %s)", version, strings.Join(HTTPVersions, ", "))
+		}
+		c.protocols = p
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHTTPVersion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+	// HTTP/1.1 と h2c に対応したサーバ
+	h2c := httptest.NewUnstartedServer(handler)
+	h2c.Config.Protocols = &http.Protocols{}
+	h2c.Config.Protocols.SetHTTP1(true)
+	h2c.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2c.Start()
+	defer h2c.Close()
+	// ALPN で HTTP/1.1 と HTTP/2 に対応したサーバ
+	h2 := httptest.NewUnstartedServer(handler)
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+	defer h2.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		version string
+		want    string
+		wantErr string
+	}{
+		{name: "Default over TLS", url: h2.URL, version: "", want: "HTTP/2.0"},
+		{name: "HTTP/1.1 over TLS", url: h2.URL, version: HTTP11, want: "HTTP/1.1"},
+		{name: "HTTP/2 over TLS", url: h2.URL, version: HTTP2, want: "HTTP/2.0"},
+		{name: "Prior knowledge over TLS", url: h2.URL, version: HTTP2PriorKnowledge, want: "HTTP/2.0"},
+		{name: "Default over cleartext", url: h2c.URL, version: "", want: "HTTP/1.1"},
+		{name: "HTTP/2 over cleartext", url: h2c.URL, version: HTTP2, want: "HTTP/1.1"},
+		{name: "Prior knowledge over cleartext", url: h2c.URL, version: HTTP2PriorKnowledge, want: "HTTP/2.0"},
+		{name: "Unsupported", url: h2c.URL, version: "3", wantErr: "unsupported HTTP version '3' (available: 1.1, 2, 2-prior-knowledge)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(tt.url, http.MethodGet, "", []string{}, WithTLS(TLSOptions{Insecure: true}), WithHTTPVersion(tt.version))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ex, err := c.Do()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ex.Close()
+
+			assert.Equal(t, tt.want, ex.Response.Proto)
+			assert.Equal(t, tt.want, string(ex.Body()))
+			assert.Contains(t, ex.ResponseText(), "[Protocol] "+tt.want+"\n")
+		})
+	}
+}
+
+func TestWithHTTPVersion_Verbose(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Config.Protocols = &http.Protocols{}
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	c, err := NewHttpClient(ts.URL+"/path", http.MethodGet, "", []string{}, WithVerbose(&buf, false), WithHTTPVersion(HTTP2PriorKnowledge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+
+	assert.Contains(t, buf.String(), "> GET /path HTTP/2\n")
+	assert.Contains(t, buf.String(), "< HTTP/2.0 200 OK\n")
+}
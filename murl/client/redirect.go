@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// -L を指定した場合にリダイレクトを追跡する回数のデフォルト値
+const DefaultMaxRedirects = 50
+
+// 別のホストへリダイレクトする際に送信しないヘッダ
+var credentialHeaders = []string{"Authorization", "Cookie", "Cookie2"}
+
+// 追跡したリダイレクトの 1 回分を表す構造体
+type Hop struct {
+	// リダイレクトのレスポンスを返した URL
+	URL string `json:"url"`
+	// リダイレクトのレスポンスのステータスコード
+	Status int `json:"status"`
+	// リダイレクトのレスポンスの Location ヘッダ
+	Location string `json:"location"`
+	// リダイレクトのレスポンスを返したリクエストのメソッド
+	Method string `json:"method"`
+	// リダイレクトのレスポンスを返したリクエストで実際に送信したヘッダ
+	RequestHeaders http.Header `json:"request_headers"`
+	// リダイレクトのレスポンスのプロトコル
+	Proto string `json:"proto"`
+	// リダイレクトのレスポンスのヘッダ
+	Headers http.Header `json:"headers"`
+
+	// リダイレクトのレスポンスを返したリクエスト
+	request *http.Request
+	// リダイレクトのレスポンスを受信した時刻
+	received time.Time
+}
+
+// リダイレクトを最大 max 回（負の場合は無制限）まで追跡する Option
+// 指定しない場合はリダイレクトを追跡せず、3xx のレスポンスをそのまま返す
+func WithFollowRedirects(max int) Option {
+	return func(c *HttpClient) error {
+		c.followRedirects = true
+		c.maxRedirects = max
+		return nil
+	}
+}
+
+// 追跡したリダイレクトを hops に記録する http.Client.CheckRedirect を返すメソッド
+// 送信したヘッダは sent から取得する
+// 最初のリクエストとホストが異なる URL へリダイレクトする場合は認証情報のヘッダを取り除く
+func (c *HttpClient) checkRedirect(hops *[]Hop, sent *sentHeaders) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if !c.followRedirects {
+			return http.ErrUseLastResponse
+		}
+		if c.maxRedirects >= 0 && len(via) > c.maxRedirects {
+			return fmt.Errorf("maximum (%d) redirects followed", c.maxRedirects)
+		}
+
+		if res := req.Response; res != nil {
+			*hops = append(*hops, Hop{
+				URL:            res.Request.URL.String(),
+				Status:         res.StatusCode,
+				Location:       res.Header.Get("Location"),
+				Method:         res.Request.Method,
+				RequestHeaders: sent.last(),
+				Proto:          res.Proto,
+				Headers:        res.Header,
+				request:        res.Request,
+				received:       time.Now(),
+			})
+		}
+		if req.URL.Host != via[0].URL.Host {
+			for _, name := range credentialHeaders {
+				req.Header.Del(name)
+			}
+		}
+		return nil
+	}
+}
+
+// 追跡したリダイレクトを 1 回ずつ所定のフォーマットで返却
+func CreateRedirectText(hops []Hop) string {
+	var b strings.Builder
+	for i, hop := range hops {
+		fmt.Fprintf(&b, "\n===Redirect %d===\n", i+1)
+		fmt.Fprintf(&b, "[URL] %s\n", hop.URL)
+		fmt.Fprintf(&b, "[Status] %d\n", hop.Status)
+		fmt.Fprintf(&b, "[Location] %s\n", hop.Location)
+	}
+	return b.String()
+}
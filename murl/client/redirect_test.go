@@ -0,0 +1,147 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// /a → /b → /c の順にリダイレクトするテスト用サーバを起動する関数
+func newRedirectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+	mux.Handle("/b", http.RedirectHandler("/c", http.StatusFound))
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("done"))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// 出力から指定したヘッダの行を取り除く関数
+func removeHeaderLine(text, name string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "  "+name+": ") {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func TestHttpClient_Execute_Redirect(t *testing.T) {
+	ts := newRedirectServer(t)
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Not followed",
+			opts: nil,
+			want: `
+===Response===
+[Status] 301
+[Protocol] HTTP/1.1
+[Headers]
+  Content-Length: 37
+  Content-Type: text/html; charset=utf-8
+  Location: /b
+[Body]
+<a href="/b">Moved Permanently</a>.
+
+
+`,
+		},
+		{
+			name: "Followed",
+			opts: []Option{WithFollowRedirects(DefaultMaxRedirects)},
+			want: `
+===Redirect 1===
+[URL] ` + ts.URL + `/a
+[Status] 301
+[Location] /b
+
+===Redirect 2===
+[URL] ` + ts.URL + `/b
+[Status] 302
+[Location] /c
+
+===Response===
+[Status] 200
+[Protocol] HTTP/1.1
+[Headers]
+  Content-Length: 4
+  Content-Type: text/plain; charset=utf-8
+[Body]
+done
+`,
+		},
+		{name: "Max redirects exceeded", opts: []Option{WithFollowRedirects(1)}, wantErr: true},
+		{name: "No limit", opts: []Option{WithFollowRedirects(-1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(ts.URL+"/a", http.MethodGet, "", nil, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, got, err := c.Execute()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "maximum (1) redirects followed")
+				return
+			}
+			assert.NoError(t, err)
+			if tt.want != "" {
+				// Date ヘッダは毎回変わるため比較しない
+				assert.Equal(t, tt.want, removeHeaderLine(got, "Date"))
+			}
+		})
+	}
+}
+
+func TestHttpClient_Execute_RedirectCredentials(t *testing.T) {
+	received := make(map[string]http.Header)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received["other"] = r.Header.Clone()
+	}))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/cross", http.RedirectHandler(other.URL, http.StatusFound))
+	mux.Handle("/same", http.RedirectHandler("/final", http.StatusFound))
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		received["same"] = r.Header.Clone()
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	headers := []string{"Authorization: Bearer token", "Cookie: session=1", "X-Custom: value"}
+	for _, path := range []string{"/cross", "/same"} {
+		c, err := NewHttpClient(ts.URL+path, http.MethodGet, "", headers, WithFollowRedirects(DefaultMaxRedirects))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := c.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 別のホストへは認証情報のヘッダを送信しない
+	assert.Empty(t, received["other"].Get("Authorization"))
+	assert.Empty(t, received["other"].Get("Cookie"))
+	assert.Equal(t, "value", received["other"].Get("X-Custom"))
+	// 同じホストへは全てのヘッダを送信する
+	assert.Equal(t, "Bearer token", received["same"].Get("Authorization"))
+	assert.Equal(t, "session=1", received["same"].Get("Cookie"))
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// レスポンスボディの表示に関する設定
+type BodyOptions struct {
+	// Content-Type が JSON, XML, HTML の場合にインデントする
+	Pretty bool
+	// インデントしたボディや --jq の結果を色付けする
+	Color bool
+	// JSON のボディから取り出す jq の形式のパス（e.g. ".items[0].name"）
+	JQ string
+	// バイナリのボディを概要の代わりに hexdump で表示する
+	Hexdump bool
+}
+
+// ボディの表示を設定する Option
+func WithBodyOptions(opts BodyOptions) Option {
+	return func(c *HttpClient) error {
+		r := &bodyRenderer{opts: opts}
+		if opts.JQ != "" {
+			steps, err := parseJQ(opts.JQ)
+			if err != nil {
+				return err
+			}
+			r.jq = steps
+		}
+		c.renderer = r
+		return nil
+	}
+}
+
+// ボディの種類
+const (
+	bodyJSON  = "json"
+	bodyXML   = "xml"
+	bodyHTML  = "html"
+	bodyOther = ""
+)
+
+// 設定に従ってボディを表示用に加工する
+type bodyRenderer struct {
+	opts BodyOptions
+	jq   []jqStep
+}
+
+// Content-Type からボディの種類を判定する関数
+func bodyKind(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return bodyOther
+	}
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return bodyJSON
+	case mediaType == "text/html":
+		return bodyHTML
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return bodyXML
+	}
+	return bodyOther
+}
+
+// raw の形式でボディを加工せずに出力するかを返すメソッド
+func (r *bodyRenderer) passthrough() bool {
+	return r.jq == nil && !r.opts.Pretty
+}
+
+// ボディを表示用に加工した結果を返すメソッド
+// summarizeBinary が true の場合、バイナリのボディは概要または hexdump に置き換える
+func (r *bodyRenderer) render(contentType string, body []byte, summarizeBinary bool) ([]byte, error) {
+	kind := bodyKind(contentType)
+	switch {
+	case r.jq != nil:
+		values, err := extractJSON(body, r.jq)
+		if err != nil {
+			return nil, err
+		}
+		lines := make([][]byte, 0, len(values))
+		for _, v := range values {
+			b, err := marshalJSON(v, r.opts.Pretty)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, b)
+		}
+		body, kind = bytes.Join(lines, []byte("\n")), bodyJSON
+	case r.opts.Pretty && kind == bodyJSON:
+		body = prettyJSON(body)
+	case r.opts.Pretty && (kind == bodyXML || kind == bodyHTML):
+		return prettyMarkup(body, kind == bodyHTML, r.opts.Color), nil
+	}
+
+	if r.opts.Color && kind == bodyJSON && (r.opts.Pretty || r.jq != nil) {
+		return colorJSON(body), nil
+	}
+	if summarizeBinary && isBinary(contentType, body) {
+		if r.opts.Hexdump {
+			return []byte(strings.TrimSuffix(hex.Dump(body), "\n")), nil
+		}
+		return []byte(binarySummary(contentType, body)), nil
+	}
+	return body, nil
+}
+
+// HTML をエスケープせずに JSON へ変換する関数
+func marshalJSON(v any, indent bool) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// バイナリのボディかを判定する関数
+// テキストの Content-Type の場合はテキストとし、それ以外は UTF-8 として不正か NUL を含む場合にバイナリとする
+func isBinary(contentType string, body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "text/") || bodyKind(contentType) != bodyOther {
+		return false
+	}
+	switch strings.SplitN(mediaType, "/", 2)[0] {
+	case "image", "audio", "video", "font":
+		return true
+	}
+	return !utf8.Valid(body) || bytes.IndexByte(body, 0) != -1
+}
+
+// バイナリのボディのサイズと種類を表す文字列を返す関数
+// Content-Type がない場合は内容から推測する
+func binarySummary(contentType string, body []byte) string {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return fmt.Sprintf("(binary body: %d bytes, %s; use --hexdump to show the content)", len(body), contentType)
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyRenderer_render(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            BodyOptions
+		contentType     string
+		body            string
+		summarizeBinary bool
+		want            string
+		wantErr         bool
+	}{
+		{name: "Plain", contentType: "application/json", body: `{"a":1}`, want: `{"a":1}`},
+		{name: "Pretty JSON", opts: BodyOptions{Pretty: true}, contentType: "application/problem+json", body: `{"a":[1,"<b>"]}`, want: "{\n  \"a\": [\n    1,\n    \"<b>\"\n  ]\n}"},
+		{name: "Invalid JSON", opts: BodyOptions{Pretty: true}, contentType: "application/json", body: `{"a":`, want: `{"a":`},
+		{
+			name:        "Colored JSON",
+			opts:        BodyOptions{Pretty: true, Color: true},
+			contentType: "application/json",
+			body:        `{"a":"x","b":-1.5e3,"c":null}`,
+			want:        "{\n  \x1b[34;1m\"a\"\x1b[0m: \x1b[32m\"x\"\x1b[0m,\n  \x1b[34;1m\"b\"\x1b[0m: \x1b[36m-1.5e3\x1b[0m,\n  \x1b[34;1m\"c\"\x1b[0m: \x1b[35mnull\x1b[0m\n}",
+		},
+		{
+			name:        "Pretty XML",
+			opts:        BodyOptions{Pretty: true},
+			contentType: "application/xml; charset=utf-8",
+			body:        `<?xml version="1.0"?><s:root xmlns:s="urn:x"><item id="1">a &amp; b</item><empty></empty><!-- note --></s:root>`,
+			want:        "<?xml version=\"1.0\"?>\n<s:root xmlns:s=\"urn:x\">\n  <item id=\"1\">a &amp; b</item>\n  <empty></empty>\n  <!-- note -->\n</s:root>",
+		},
+		{
+			name:        "Pretty HTML",
+			opts:        BodyOptions{Pretty: true},
+			contentType: "text/html",
+			body:        `<!DOCTYPE html><html><body><p class=x>hi<br>there</p><img src="a.png"></body></html>`,
+			want:        "<!DOCTYPE html>\n<html>\n  <body>\n    <p class=\"x\">\n      hi\n      <br>\n      there\n    </p>\n    <img src=\"a.png\">\n  </body>\n</html>",
+		},
+		{name: "JQ", opts: BodyOptions{JQ: ".items[].name"}, contentType: "application/json", body: `{"items":[{"name":"a"},{"name":"b"}]}`, want: "\"a\"\n\"b\""},
+		{name: "JQ pretty", opts: BodyOptions{JQ: ".items[0]", Pretty: true}, contentType: "text/plain", body: `{"items":[{"name":"a"}]}`, want: "{\n  \"name\": \"a\"\n}"},
+		{name: "JQ not JSON", opts: BodyOptions{JQ: ".a"}, contentType: "text/plain", body: `plain`, wantErr: true},
+		{name: "Binary summary", contentType: "image/png", body: "\x89PNG\r\n\x1a\n", summarizeBinary: true, want: "(binary body: 8 bytes, image/png; use --hexdump to show the content)"},
+		{name: "Binary summary detected type", contentType: "", body: "\x89PNG\r\n\x1a\n\x00", summarizeBinary: true, want: "(binary body: 9 bytes, image/png; use --hexdump to show the content)"},
+		{name: "Binary hexdump", opts: BodyOptions{Hexdump: true}, contentType: "application/x-protobuf", body: "\x08\x96\x01", summarizeBinary: true, want: "00000000  08 96 01                                          |...|"},
+		{name: "Binary raw", contentType: "application/octet-stream", body: "\x00\x01", want: "\x00\x01"},
+		{name: "Text is not binary", contentType: "application/octet-stream", body: "hello", summarizeBinary: true, want: "hello"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(testURL, http.MethodGet, "", nil, WithBodyOptions(tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.renderer.render(tt.contentType, []byte(tt.body), tt.summarizeBinary)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestHttpClient_Execute_BinaryBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/gif")
+		w.Write([]byte("GIF89a\x01\x00"))
+	}))
+	defer ts.Close()
+
+	c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, got, err := c.Execute()
+	assert.NoError(t, err)
+	// バイナリのボディをそのまま [Body] に出力しない
+	assert.Contains(t, got, "[Body]\n(binary body: 8 bytes, image/gif; use --hexdump to show the content)\n")
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// リトライ時の最初の待ち時間のデフォルト値
+	defaultBackoff = time.Second
+	// リトライ時の待ち時間の上限のデフォルト値
+	defaultMaxBackoff = 10 * time.Minute
+)
+
+// 接続エラーや 429, 5xx のレスポンスに対して、指数関数的に待ち時間を伸ばしながらリクエストを再送する http.RoundTripper
+// Retry-After ヘッダが返された場合はその時間だけ待つ
+// リクエストボディは http.Request.GetBody で作り直すため、GetBody が設定されていないリクエストは再送しない
+type RetryTransport struct {
+	// 実際にリクエストを送信する http.RoundTripper（nil の場合は http.DefaultTransport）
+	Base http.RoundTripper
+	// 再送する最大回数
+	Retries int
+	// 接続エラーや 429, 5xx 以外に、全てのエラーと 4xx のレスポンスも再送の対象にする
+	RetryAllErrors bool
+	// 最初の待ち時間（0 の場合は 1 秒）。再送のたびに 2 倍になる
+	Backoff time.Duration
+	// 待ち時間の上限（0 の場合は 10 分）
+	MaxBackoff time.Duration
+	// nil でない場合、再送する前に呼び出される
+	OnRetry func(attempt int, wait time.Duration, reason string)
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 {
+			var err error
+			if r, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := t.base().RoundTrip(r)
+		reason, retry := t.shouldRetry(req.Context(), res, err)
+		if !retry || attempt > t.Retries || (req.Body != nil && req.GetBody == nil) {
+			return res, err
+		}
+
+		wait := t.backoff(attempt)
+		if res != nil {
+			if d, ok := retryAfter(res.Header.Get("Retry-After")); ok {
+				wait = d
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		if t.OnRetry != nil {
+			t.OnRetry(attempt, wait, reason)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *RetryTransport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// レスポンスやエラーの内容から、再送すべきかとその理由を返すメソッド
+func (t *RetryTransport) shouldRetry(ctx context.Context, res *http.Response, err error) (string, bool) {
+	if ctx.Err() != nil {
+		return "", false
+	}
+	// --connect-timeout による接続のタイムアウトも接続エラーとして再送する
+	if err != nil {
+		return err.Error(), true
+	}
+
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests, res.StatusCode >= 500:
+		return res.Status, true
+	case t.RetryAllErrors && res.StatusCode >= 400:
+		return res.Status, true
+	}
+	return "", false
+}
+
+// attempt 回目の再送までの待ち時間を返すメソッド
+// 待ち時間の半分を基準に、残りの半分の範囲でランダムに揺らす
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d, max := t.Backoff, t.MaxBackoff
+	if d <= 0 {
+		d = defaultBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	d = min(d, max)
+	return d/2 + rand.N(d/2+1)
+}
+
+// Retry-After ヘッダの値（秒数または HTTP の日付）を待ち時間に変換する関数
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// 再送するためにリクエストボディを作り直したリクエストを返す関数
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.GetBody == nil {
+		return r, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	r.Body = body
+	return r, nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 接続を受け付けないアドレスを返す関数
+// バックログが 0 のソケットを 1 つの接続で埋め、以降の接続要求には応答しないようにする
+func newUnansweredAddr(t *testing.T) string {
+	t.Helper()
+
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { syscall.Close(fd) })
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Listen(fd, 0); err != nil {
+		t.Fatal(err)
+	}
+	sa, err := syscall.Getsockname(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(sa.(*syscall.SockaddrInet4).Port))
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return addr
+}
+
+func TestHttpClient_Execute_RetryConnectTimeout(t *testing.T) {
+	const retries = 2
+	attempts := 1
+	c, err := NewHttpClient("http://"+newUnansweredAddr(t)+"/", http.MethodGet, "", nil,
+		WithTimeout(50*time.Millisecond, 0),
+		WithRetry(retries, false, func(attempt int, wait time.Duration, reason string) { attempts++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.retry.Backoff = time.Millisecond
+
+	_, _, err = c.Execute()
+	assert.ErrorContains(t, err, "i/o timeout")
+	assert.Equal(t, retries+1, attempts)
+}
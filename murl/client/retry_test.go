@@ -0,0 +1,190 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 指定したステータスコードを順に返すテスト用サーバを起動する関数
+// 全て返した後は 200 を返す。リクエストボディは受け取った順に bodies へ保存する
+func newSequenceServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32, *[]string) {
+	t.Helper()
+
+	count := new(atomic.Int32)
+	bodies := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+
+		n := int(count.Add(1))
+		if n <= len(statuses) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(ts.Close)
+	return ts, count, &bodies
+}
+
+func TestRetryTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name           string
+		retries        int
+		retryAllErrors bool
+		statuses       []int
+		wantStatus     int
+		wantCount      int32
+	}{
+		{name: "Success", retries: 3, statuses: nil, wantStatus: http.StatusOK, wantCount: 1},
+		{name: "Retry 5xx", retries: 3, statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError}, wantStatus: http.StatusOK, wantCount: 3},
+		{name: "Retry 429", retries: 3, statuses: []int{http.StatusTooManyRequests}, wantStatus: http.StatusOK, wantCount: 2},
+		{name: "Retries exhausted", retries: 1, statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, wantStatus: http.StatusBadGateway, wantCount: 2},
+		{name: "Not retry 4xx", retries: 3, statuses: []int{http.StatusNotFound}, wantStatus: http.StatusNotFound, wantCount: 1},
+		{name: "Retry all errors", retries: 3, retryAllErrors: true, statuses: []int{http.StatusNotFound}, wantStatus: http.StatusOK, wantCount: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, count, _ := newSequenceServer(t, nil, tt.statuses...)
+			rt := &RetryTransport{Retries: tt.retries, RetryAllErrors: tt.retryAllErrors, Backoff: time.Millisecond}
+
+			req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+			res, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantCount, count.Load())
+		})
+	}
+}
+
+func TestRetryTransport_RoundTrip_Body(t *testing.T) {
+	ts, _, bodies := newSequenceServer(t, nil, http.StatusServiceUnavailable)
+	rt := &RetryTransport{Retries: 1, Backoff: time.Millisecond}
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"key":"value"}`))
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// 再送時も同じリクエストボディを送信する
+	assert.Equal(t, []string{`{"key":"value"}`, `{"key":"value"}`}, *bodies)
+}
+
+func TestRetryTransport_RoundTrip_RetryAfter(t *testing.T) {
+	ts, _, _ := newSequenceServer(t, http.Header{"Retry-After": {"0"}}, http.StatusServiceUnavailable)
+
+	waits := make([]time.Duration, 0)
+	rt := &RetryTransport{
+		Retries: 1,
+		Backoff: time.Hour,
+		OnRetry: func(attempt int, wait time.Duration, reason string) {
+			waits = append(waits, wait)
+			assert.Equal(t, "503 Service Unavailable", reason)
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	// Retry-After が指定されている場合は Backoff より優先する
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []time.Duration{0}, waits)
+}
+
+func TestRetryTransport_RoundTrip_ConnectionError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	attempts := 0
+	rt := &RetryTransport{
+		Retries: 2,
+		Backoff: time.Millisecond,
+		OnRetry: func(attempt int, wait time.Duration, reason string) { attempts = attempt },
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	_, err := rt.RoundTrip(req)
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetryTransport_backoff(t *testing.T) {
+	rt := &RetryTransport{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 4, min: 400 * time.Millisecond, max: 800 * time.Millisecond},
+		{attempt: 10, min: 500 * time.Millisecond, max: time.Second},
+	}
+	for _, tt := range tests {
+		for range 10 {
+			got := rt.backoff(tt.attempt)
+			assert.GreaterOrEqual(t, got, tt.min, "attempt: %d", tt.attempt)
+			assert.LessOrEqual(t, got, tt.max, "attempt: %d", tt.attempt)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "Seconds", value: "3", want: 3 * time.Second, wantOK: true},
+		{name: "Past date", value: "Wed, 04 Jan 2023 08:26:15 GMT", want: 0, wantOK: true},
+		{name: "Empty", value: "", want: 0, wantOK: false},
+		{name: "Negative", value: "-1", want: 0, wantOK: false},
+		{name: "Invalid", value: "soon", want: 0, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.value)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestHttpClient_Execute_MaxTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithTimeout(0, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.Execute()
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// "provider1:provider2:region:service" の形式（e.g. "aws:amz:us-east-1:s3"）で指定した内容と
+// "access_key:secret_key" の形式の認証情報で、AWS Signature Version 4 の署名を行う Option
+func WithAWSSigV4(spec, credentials string) Option {
+	return func(c *HttpClient) error {
+		if spec == "" {
+			return nil
+		}
+		s, err := newSigV4Signer(spec, credentials)
+		if err != nil {
+			return err
+		}
+		return WithDecorator(s.sign)(c)
+	}
+}
+
+// AWS Signature Version 4 の署名を行うための設定
+type sigV4Signer struct {
+	// "aws" など、アルゴリズム名や鍵の接頭辞に使う名前
+	provider1 string
+	// "amz" など、ヘッダ名の接頭辞に使う名前
+	provider2 string
+	region    string
+	service   string
+	accessKey string
+	secretKey string
+	now       func() time.Time
+}
+
+// 設定と認証情報を解析して sigV4Signer を生成するファクトリ関数
+func newSigV4Signer(spec, credentials string) (*sigV4Signer, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 4 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("invalid aws-sigv4 '%s' (expected PROVIDER1:PROVIDER2:REGION:SERVICE)", spec)
+	}
+	access, secret, ok := strings.Cut(credentials, ":")
+	if !ok || access == "" || secret == "" {
+		return nil, fmt.Errorf("aws-sigv4 requires credentials in the form of ACCESS_KEY:SECRET_KEY")
+	}
+
+	return &sigV4Signer{
+		provider1: strings.ToLower(parts[0]),
+		provider2: strings.ToLower(parts[1]),
+		region:    parts[2],
+		service:   parts[3],
+		accessKey: access,
+		secretKey: secret,
+		now:       time.Now,
+	}, nil
+}
+
+// リクエストに日時・ペイロードのハッシュのヘッダと、署名した Authorization ヘッダを設定するメソッド
+// Authorization 以外の全てのヘッダと Host を署名の対象とする
+func (s *sigV4Signer) sign(req *http.Request) error {
+	payload, err := payloadHash(req)
+	if err != nil {
+		return err
+	}
+
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	prefix := "X-" + strings.ToUpper(s.provider2[:1]) + s.provider2[1:]
+	req.Header.Set(prefix+"-Date", amzDate)
+	req.Header.Set(prefix+"-Content-Sha256", payload)
+
+	headers := map[string]string{"host": req.Host}
+	if req.Host == "" {
+		headers["host"] = req.URL.Host
+	}
+	for name, values := range req.Header {
+		if name == "Authorization" {
+			continue
+		}
+		trimmed := make([]string, 0, len(values))
+		for _, v := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+
+	algorithm := strings.ToUpper(s.provider1) + "4-HMAC-SHA256"
+	scope := strings.Join([]string{t.Format("20060102"), s.region, s.service, s.provider1 + "4_request"}, "/")
+	stringToSign := strings.Join([]string{algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte(strings.ToUpper(s.provider1) + "4" + s.secretKey)
+	for _, v := range []string{t.Format("20060102"), s.region, s.service, s.provider1 + "4_request"} {
+		key = hmacSHA256(key, v)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, s.accessKey, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+// リクエストボディの SHA-256 ハッシュを 16 進数で返す関数
+func payloadHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return sha256Hex(nil), nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(b), nil
+}
+
+// クエリパラメータをキーと値の昇順に並べ、RFC 3986 に従ってエンコードした文字列を返す関数
+func canonicalQuery(query url.Values) string {
+	escape := func(s string) string {
+		return strings.NewReplacer("+", "%20", "%7E", "~").Replace(url.QueryEscape(s))
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(query))
+	for _, key := range keys {
+		values := slices.Clone(query[key])
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, escape(key)+"="+escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TLS の接続に関する設定
+type TLSOptions struct {
+	// サーバ証明書の検証に使う CA 証明書の PEM ファイル
+	CACert string
+	// サーバ証明書の検証に使う CA 証明書の PEM ファイルを置いたディレクトリ
+	CAPath string
+	// クライアント証明書の PEM ファイル（秘密鍵を含む場合は Key を省略できる）
+	Cert string
+	// クライアント証明書の秘密鍵の PEM ファイル
+	Key string
+	// サーバ証明書を検証しない
+	Insecure bool
+	// 許容する TLS の最小バージョン（0 の場合は Go のデフォルト）
+	MinVersion uint16
+	// "sha256//BASE64" の形式で指定したサーバの公開鍵のハッシュ（";" で複数指定可能）
+	PinnedPubKey string
+	// レスポンスにネゴシエートした TLS のバージョン、暗号スイート、証明書チェーンを出力する
+	Show bool
+}
+
+// TLS の接続の設定を変更する Option
+// CACert, CAPath を指定した場合はシステムの CA 証明書の代わりに使う
+func WithTLS(opts TLSOptions) Option {
+	return func(c *HttpClient) error {
+		c.showTLS = opts.Show
+
+		cfg := &tls.Config{InsecureSkipVerify: opts.Insecure, MinVersion: opts.MinVersion}
+		if opts.CACert != "" || opts.CAPath != "" {
+			pool, err := loadCertPool(opts.CACert, opts.CAPath)
+			if err != nil {
+				return err
+			}
+			cfg.RootCAs = pool
+		}
+
+		if opts.Cert != "" {
+			key := opts.Key
+			if key == "" {
+				key = opts.Cert
+			}
+			cert, err := tls.LoadX509KeyPair(opts.Cert, key)
+			if err != nil {
+				return fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		} else if opts.Key != "" {
+			return errors.New("--key requires --cert")
+		}
+
+		if opts.PinnedPubKey != "" {
+			pins, err := parsePinnedPubKey(opts.PinnedPubKey)
+			if err != nil {
+				return err
+			}
+			cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+				return verifyPinnedPubKey(cs, pins)
+			}
+		}
+
+		c.tlsConfig = cfg
+		return nil
+	}
+}
+
+// CA 証明書のファイルと、ディレクトリ内の全てのファイルから証明書を読み込む関数
+func loadCertPool(file, dir string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if file != "" {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in '%s'", file)
+		}
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				return nil, err
+			}
+			// 証明書以外のファイルは無視する
+			pool.AppendCertsFromPEM(b)
+		}
+	}
+	return pool, nil
+}
+
+// "sha256//BASE64;sha256//BASE64" の形式の値をハッシュのリストに変換する関数
+func parsePinnedPubKey(s string) ([][]byte, error) {
+	pins := make([][]byte, 0)
+	for _, p := range strings.Split(s, ";") {
+		encoded, ok := strings.CutPrefix(strings.TrimSpace(p), "sha256//")
+		if !ok {
+			return nil, fmt.Errorf("invalid pinned public key '%s' (expected sha256//BASE64)", p)
+		}
+		hash, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("invalid pinned public key '%s' (expected sha256//BASE64)", p)
+		}
+		pins = append(pins, hash)
+	}
+	return pins, nil
+}
+
+// サーバ証明書の公開鍵のハッシュがいずれかの値に一致するかを検証する関数
+func verifyPinnedPubKey(cs tls.ConnectionState, pins [][]byte) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("pinned public key mismatch: no server certificate")
+	}
+	hash := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+	if slices.ContainsFunc(pins, func(pin []byte) bool { return string(pin) == string(hash[:]) }) {
+		return nil
+	}
+	return fmt.Errorf("pinned public key mismatch: server key is sha256//%s", base64.StdEncoding.EncodeToString(hash[:]))
+}
+
+// ネゴシエートした TLS のバージョン、暗号スイート、証明書チェーンを所定のフォーマットで返却
+func CreateTLSText(cs *tls.ConnectionState) string {
+	if cs == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("[TLS]\n")
+	fmt.Fprintf(&b, "  Version: %s\n", tls.VersionName(cs.Version))
+	fmt.Fprintf(&b, "  Cipher: %s\n", tls.CipherSuiteName(cs.CipherSuite))
+	if cs.NegotiatedProtocol != "" {
+		fmt.Fprintf(&b, "  ALPN: %s\n", cs.NegotiatedProtocol)
+	}
+	b.WriteString("  Certificates:\n")
+	for i, cert := range cs.PeerCertificates {
+		fmt.Fprintf(&b, "    %d: %s (issuer: %s, expires: %s)\n", i, cert.Subject, cert.Issuer, cert.NotAfter.UTC().Format(time.DateOnly))
+	}
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// テスト用サーバの証明書を PEM ファイルとして書き出し、そのパスを返す関数
+func writeServerCert(t *testing.T, ts *httptest.Server) string {
+	t.Helper()
+	return writeTestFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})))
+}
+
+// 自己署名のクライアント証明書と秘密鍵を PEM ファイルとして書き出し、証明書とそのパスを返す関数
+func writeClientCert(t *testing.T) (*x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "murl-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath := writeTestFile(t, "client.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	keyPath := writeTestFile(t, "client.key", string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return cert, certPath, keyPath
+}
+
+func TestHttpClient_Execute_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	caCert := writeServerCert(t, ts)
+	caPath := filepath.Dir(caCert)
+	hash := sha256.Sum256(ts.Certificate().RawSubjectPublicKeyInfo)
+	pin := "sha256//" + base64.StdEncoding.EncodeToString(hash[:])
+	otherPin := "sha256//" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr string
+	}{
+		{name: "Unknown CA", opts: TLSOptions{}, wantErr: "certificate"},
+		{name: "CA cert", opts: TLSOptions{CACert: caCert}},
+		{name: "CA path", opts: TLSOptions{CAPath: caPath}},
+		{name: "Insecure", opts: TLSOptions{Insecure: true}},
+		{name: "Pinned public key", opts: TLSOptions{CACert: caCert, PinnedPubKey: otherPin + ";" + pin}},
+		{name: "Pinned public key mismatch", opts: TLSOptions{Insecure: true, PinnedPubKey: otherPin}, wantErr: "pinned public key mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithTLS(tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = c.Execute()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHttpClient_Execute_ClientCert(t *testing.T) {
+	cert, certPath, keyPath := writeClientCert(t)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	ts.StartTLS()
+	defer ts.Close()
+
+	// 証明書と秘密鍵を 1 つのファイルにまとめた場合
+	combined := filepath.Join(t.TempDir(), "combined.pem")
+	certPEM, _ := os.ReadFile(certPath)
+	keyPEM, _ := os.ReadFile(keyPath)
+	if err := os.WriteFile(combined, append(certPEM, keyPEM...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{name: "Cert and key", opts: TLSOptions{Insecure: true, Cert: certPath, Key: keyPath}},
+		{name: "Combined", opts: TLSOptions{Insecure: true, Cert: combined}},
+		{name: "No client cert", opts: TLSOptions{Insecure: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithTLS(tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, got, err := c.Execute()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, got, "[Body]\nmurl-client\n")
+		})
+	}
+}
+
+func TestHttpClient_Execute_TLSVersion(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		minVersion uint16
+		wantErr    bool
+	}{
+		{name: "TLS 1.2", minVersion: tls.VersionTLS12},
+		{name: "TLS 1.3", minVersion: tls.VersionTLS13, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithTLS(TLSOptions{Insecure: true, MinVersion: tt.minVersion, Show: true}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, got, err := c.Execute()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, got, "[TLS]\n  Version: TLS 1.2\n  Cipher: TLS_")
+			assert.Contains(t, got, "  Certificates:\n    0: O=Acme Co (issuer: O=Acme Co, expires: ")
+		})
+	}
+}
+
+func TestWithTLS_Error(t *testing.T) {
+	notPEM := writeTestFile(t, "not.pem", "not a certificate")
+
+	tests := []struct {
+		name string
+		opts TLSOptions
+	}{
+		{name: "Missing CA cert", opts: TLSOptions{CACert: "./not_exist.pem"}},
+		{name: "Invalid CA cert", opts: TLSOptions{CACert: notPEM}},
+		{name: "Missing CA path", opts: TLSOptions{CAPath: "./not_exist"}},
+		{name: "Invalid client cert", opts: TLSOptions{Cert: notPEM}},
+		{name: "Key without cert", opts: TLSOptions{Key: notPEM}},
+		{name: "Invalid pin prefix", opts: TLSOptions{PinnedPubKey: "md5//abc"}},
+		{name: "Invalid pin length", opts: TLSOptions{PinnedPubKey: "sha256//YWJj"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewHttpClient(testURL, http.MethodGet, "", nil, WithTLS(tt.opts))
+			assert.Error(t, err)
+		})
+	}
+}
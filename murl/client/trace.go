@@ -0,0 +1,220 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 接続の経過と、送受信したヘッダの行を w に出力する Option（curl の -v 相当）
+// traceTime が true の場合は各行の先頭に時刻を出力する
+// レスポンスにはボディを読み込むまでの各段階の所要時間を出力する
+func WithVerbose(w io.Writer, traceTime bool) Option {
+	return func(c *HttpClient) error {
+		if w == nil {
+			return nil
+		}
+		c.verbose = &verboseLogger{w: w, traceTime: traceTime}
+		return nil
+	}
+}
+
+// 接続の各段階の時刻
+// リダイレクトや再送で複数回接続した場合は最後の接続の時刻を記録する
+type Timing struct {
+	// リクエストを開始した時刻
+	Start time.Time
+	// 名前解決を開始・完了した時刻
+	DNSStart, DNSDone time.Time
+	// TCP の接続を開始・完了した時刻
+	ConnectStart, ConnectDone time.Time
+	// TLS のハンドシェイクを開始・完了した時刻
+	TLSStart, TLSDone time.Time
+	// リクエストを送信できる接続を得た時刻
+	GotConn time.Time
+	// リクエストを送信し終えた時刻
+	WroteRequest time.Time
+	// レスポンスの最初の 1 バイトを受信した時刻
+	FirstByte time.Time
+	// レスポンスのボディを読み込み終えた時刻
+	Done time.Time
+	// 接続先のアドレス
+	RemoteAddr string
+}
+
+// 各段階の時刻を記録する httptrace.ClientTrace を返すメソッド
+func (t *Timing) trace() *httptrace.ClientTrace {
+	var mu sync.Mutex
+	set := func(p *time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		*p = time.Now()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { set(&t.DNSStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { set(&t.DNSDone) },
+		ConnectStart:      func(string, string) { set(&t.ConnectStart) },
+		ConnectDone:       func(string, string, error) { set(&t.ConnectDone) },
+		TLSHandshakeStart: func() { set(&t.TLSStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { set(&t.TLSDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			set(&t.GotConn)
+			mu.Lock()
+			defer mu.Unlock()
+			t.RemoteAddr = info.Conn.RemoteAddr().String()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.WroteRequest) },
+		GotFirstResponseByte: func() { set(&t.FirstByte) },
+	}
+}
+
+// 名前解決にかかった時間
+func (t *Timing) DNSLookup() time.Duration {
+	return between(t.DNSStart, t.DNSDone)
+}
+
+// TCP の接続にかかった時間
+func (t *Timing) Connect() time.Duration {
+	return between(t.ConnectStart, t.ConnectDone)
+}
+
+// TLS のハンドシェイクにかかった時間
+func (t *Timing) TLSHandshake() time.Duration {
+	return between(t.TLSStart, t.TLSDone)
+}
+
+// リクエストを開始してからレスポンスの最初の 1 バイトを受信するまでの時間
+func (t *Timing) TimeToFirstByte() time.Duration {
+	return between(t.Start, t.FirstByte)
+}
+
+// リクエストを開始してからレスポンスのボディを読み込み終えるまでの時間
+func (t *Timing) Total() time.Duration {
+	return between(t.Start, t.Done)
+}
+
+// start から end までの時間を返す関数（どちらかが記録されていない場合は 0）
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// 各段階の所要時間を所定のフォーマットで返却
+func CreateTimingText(t *Timing) string {
+	var b strings.Builder
+	b.WriteString("[Timing]\n")
+	fmt.Fprintf(&b, "  DNS Lookup: %s\n", t.DNSLookup().Round(time.Microsecond))
+	fmt.Fprintf(&b, "  TCP Connect: %s\n", t.Connect().Round(time.Microsecond))
+	fmt.Fprintf(&b, "  TLS Handshake: %s\n", t.TLSHandshake().Round(time.Microsecond))
+	fmt.Fprintf(&b, "  Time to First Byte: %s\n", t.TimeToFirstByte().Round(time.Microsecond))
+	fmt.Fprintf(&b, "  Total: %s\n", t.Total().Round(time.Microsecond))
+	return b.String()
+}
+
+// 接続の経過を "* "、送信したヘッダを "> "、受信したヘッダを "< " から始まる行で出力するロガー
+type verboseLogger struct {
+	mu        sync.Mutex
+	w         io.Writer
+	traceTime bool
+}
+
+func (l *verboseLogger) printf(prefix, format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.traceTime {
+		fmt.Fprint(l.w, time.Now().Format("15:04:05.000000 "))
+	}
+	fmt.Fprintf(l.w, prefix+format+"\n", args...)
+}
+
+// リクエストごとに接続の経過と送受信したヘッダを出力する http.RoundTripper
+// 再送やリダイレクト、Digest 認証のチャレンジによるリクエストも 1 回ずつ出力する
+type verboseTransport struct {
+	Base http.RoundTripper
+	log  *verboseLogger
+	// http の URL へ h2c で送信するか
+	h2c bool
+}
+
+func (t *verboseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.log
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			l.printf("* ", "Resolving %s", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err != nil {
+				l.printf("* ", "Could not resolve host: %s", info.Err)
+				return
+			}
+			addrs := make([]string, 0, len(info.Addrs))
+			for _, a := range info.Addrs {
+				addrs = append(addrs, a.String())
+			}
+			l.printf("* ", "Resolved to %s", strings.Join(addrs, ", "))
+		},
+		ConnectStart: func(network, addr string) {
+			l.printf("* ", "Trying %s...", addr)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				l.printf("* ", "Failed to connect to %s: %s", addr, err)
+				return
+			}
+			l.printf("* ", "Connected to %s", addr)
+		},
+		TLSHandshakeStart: func() {
+			l.printf("* ", "TLS handshake")
+		},
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err != nil {
+				l.printf("* ", "TLS handshake failed: %s", err)
+				return
+			}
+			l.printf("* ", "TLS handshake done: %s / %s", tls.VersionName(cs.Version), tls.CipherSuiteName(cs.CipherSuite))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				l.printf("* ", "Re-using existing connection to %s", info.Conn.RemoteAddr())
+			}
+			proto := "HTTP/1.1"
+			if tc, ok := info.Conn.(*tls.Conn); ok && tc.ConnectionState().NegotiatedProtocol == "h2" {
+				proto = "HTTP/2"
+			} else if t.h2c && req.URL.Scheme == "http" {
+				proto = "HTTP/2"
+			}
+			l.printf("> ", "%s %s %s", req.Method, req.URL.RequestURI(), proto)
+		},
+		WroteHeaderField: func(key string, values []string) {
+			for _, v := range values {
+				l.printf("> ", "%s: %s", key, v)
+			}
+		},
+		WroteHeaders: func() {
+			l.printf(">", "")
+		},
+	}
+
+	res, err := t.Base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		return nil, err
+	}
+
+	l.printf("< ", "%s %s", res.Proto, res.Status)
+	for _, k := range sortedKeys(res.Header) {
+		for _, v := range res.Header[k] {
+			l.printf("< ", "%s: %s", k, v)
+		}
+	}
+	l.printf("<", "")
+	return res, nil
+}
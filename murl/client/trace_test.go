@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 出力から prefix で始まる行を取り除く関数
+func removeVerboseLine(text, prefix string) string {
+	lines := strings.SplitAfter(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if !strings.HasPrefix(l, prefix) {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+func TestHttpClient_Do_Verbose(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Server", "test")
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	var log bytes.Buffer
+	c, err := NewHttpClient(ts.URL+"/path?q=1", http.MethodGet, "", []string{"X-Custom: value"}, WithVerbose(&log, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	got := ex.ResponseText()
+
+	host := strings.TrimPrefix(ts.URL, "http://")
+	assert.Equal(t, strings.Join([]string{
+		"* Trying " + host + "...",
+		"* Connected to " + host,
+		"> GET /path?q=1 HTTP/1.1",
+		"> Host: " + host,
+		"> User-Agent: Go-http-client/1.1",
+		"> X-Custom: value",
+		"> Accept-Encoding: gzip",
+		">",
+		"< HTTP/1.1 200 OK",
+		"< Content-Length: 2",
+		"< Content-Type: text/plain; charset=utf-8",
+		"< X-Server: test",
+		"<",
+		"",
+	}, "\n"), removeVerboseLine(log.String(), "< Date: "))
+
+	assert.Contains(t, got, "[Body]\nok\n[Timing]\n  DNS Lookup: 0s\n  TCP Connect: ")
+	assert.Positive(t, ex.Timing.TimeToFirstByte())
+	assert.GreaterOrEqual(t, ex.Timing.Total(), ex.Timing.TimeToFirstByte())
+}
+
+func TestHttpClient_Do_TraceTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var log bytes.Buffer
+	c, err := NewHttpClient(ts.URL, http.MethodGet, "", nil, WithVerbose(&log, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 各行の先頭に時刻を出力する
+	for _, line := range strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n") {
+		assert.Regexp(t, `^\d{2}:\d{2}:\d{2}\.\d{6} [*<>]`, line)
+	}
+}
+
+func TestCreateTimingText(t *testing.T) {
+	start := time.Date(2023, 1, 4, 8, 26, 15, 0, time.UTC)
+	at := func(ms float64) time.Time { return start.Add(time.Duration(ms * float64(time.Millisecond))) }
+
+	tests := []struct {
+		name   string
+		timing *Timing
+		want   string
+	}{
+		{
+			name: "New connection",
+			timing: &Timing{
+				Start:    start,
+				DNSStart: at(0.1), DNSDone: at(1.5),
+				ConnectStart: at(1.5), ConnectDone: at(3.25),
+				TLSStart: at(3.25), TLSDone: at(13.25),
+				FirstByte: at(40), Done: at(42.5),
+			},
+			want: `[Timing]
+  DNS Lookup: 1.4ms
+  TCP Connect: 1.75ms
+  TLS Handshake: 10ms
+  Time to First Byte: 40ms
+  Total: 42.5ms
+`,
+		},
+		{
+			name:   "Reused connection",
+			timing: &Timing{Start: start, FirstByte: at(2), Done: at(3)},
+			want: `[Timing]
+  DNS Lookup: 0s
+  TCP Connect: 0s
+  TLS Handshake: 0s
+  Time to First Byte: 2ms
+  Total: 3ms
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CreateTimingText(tt.timing))
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// 接続のタイムアウト、リクエスト全体のタイムアウトを設定する Option
+// 0 の場合はタイムアウトしない
+func WithTimeout(connect, max time.Duration) Option {
+	return func(c *HttpClient) error {
+		c.connectTimeout = connect
+		c.maxTime = max
+		return nil
+	}
+}
+
+// 接続エラーや 429, 5xx のレスポンスに対して最大 n 回再送する Option
+// allErrors が true の場合は全てのエラーと 4xx のレスポンスも再送の対象にする
+// onRetry が nil でない場合は再送する前に呼び出される
+func WithRetry(n int, allErrors bool, onRetry func(attempt int, wait time.Duration, reason string)) Option {
+	return func(c *HttpClient) error {
+		if n <= 0 {
+			return nil
+		}
+		c.retry = &RetryTransport{Retries: n, RetryAllErrors: allErrors, OnRetry: onRetry}
+		return nil
+	}
+}
+
+// 設定に応じてリクエストを送信するための *http.Client を返すメソッド
+func (c *HttpClient) httpClient() *http.Client {
+	hc := &http.Client{Transport: c.transport(), Timeout: c.maxTime}
+	if c.cookies != nil {
+		hc.Jar = c.cookies
+	}
+	return hc
+}
+
+// 設定に応じた http.RoundTripper を返すメソッド
+// http.DefaultTransport を複製した上で設定を反映する
+func (c *HttpClient) transport() http.RoundTripper {
+	rt := http.DefaultTransport
+	if t, ok := rt.(*http.Transport); ok {
+		t = t.Clone()
+		if c.connectTimeout > 0 {
+			t.DialContext = (&net.Dialer{Timeout: c.connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+			t.TLSHandshakeTimeout = c.connectTimeout
+		}
+		if c.tlsConfig != nil {
+			t.TLSClientConfig = c.tlsConfig.Clone()
+		}
+		if c.proxy != nil {
+			t.Proxy = c.proxy.proxyURL
+		}
+		if c.protocols != nil {
+			t.Protocols = c.protocols
+		}
+		rt = t
+	}
+
+	if c.verbose != nil {
+		rt = &verboseTransport{Base: rt, log: c.verbose, h2c: c.protocols != nil && c.protocols.UnencryptedHTTP2() && !c.protocols.HTTP1()}
+	}
+	if c.digest != nil {
+		digest := *c.digest
+		digest.Base = rt
+		rt = &digest
+	}
+	if c.retry != nil {
+		retry := *c.retry
+		retry.Base = rt
+		rt = &retry
+	}
+	return rt
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -24,9 +25,11 @@ func ValidateFlags(
 		return err
 	}
 
-	// dataのフォーマットをチェック
-	if err := validateData(data); err != nil {
-		return err
+	// dataのフォーマットをチェック（Content-Type が JSON 以外に指定されている場合は検証しない）
+	if isJSONContentType(headerValue(customHeaders, "Content-Type")) {
+		if err := validateData(data); err != nil {
+			return err
+		}
 	}
 
 	// customHeadersのフォーマットをチェック
@@ -49,12 +52,34 @@ func validateRawURL(rawurl string) error {
 	return nil
 }
 
+// 標準のメソッドに加えて、RFC 9110 の token として正しい任意のメソッドを許容する
 func validateMethod(method string) error {
-	switch method {
-	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch:
-		return nil
+	if !isToken(method) {
+		return fmt.Errorf("HTTP method '%s' is not a valid token", method)
+	}
+	return nil
+}
+
+// 文字列が RFC 9110 の token（1 文字以上の tchar）であるかを返す関数
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if !isTokenChar(c) {
+			return false
+		}
 	}
-	return fmt.Errorf("HTTP method '%s' is not supported", method)
+	return true
+}
+
+// tchar = "!" / "#" / "$" / "%" / "&" / "'" / "*" / "+" / "-" / "." / "^" / "_" / "`" / "|" / "~" / DIGIT / ALPHA
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) != -1
 }
 
 func validateData(data string) error {
@@ -69,15 +94,34 @@ func validateData(data string) error {
 	return nil
 }
 
+// Content-Type が未指定か JSON であるかを返す関数
+func isJSONContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// "名前:値" の形式のヘッダのうち、name に該当する最後のものの値を返す関数
+func headerValue(customHeaders []string, name string) string {
+	value := ""
+	for _, s := range customHeaders {
+		h, err := parseHeader(s)
+		if err == nil && h.name == http.CanonicalHeaderKey(name) {
+			value = h.value
+		}
+	}
+	return value
+}
+
 func validateHeader(customHeaders []string) error {
 	for _, v := range customHeaders {
-		s := strings.TrimSpace(v)
-		kv := strings.Split(s, ":")
-		if len(kv) != 2 {
-			return fmt.Errorf("invalid format header: %s", s)
-		}
-		if len(kv[0]) == 0 || len(kv[1]) == 0 {
-			return fmt.Errorf("invalid format header: %s", s)
+		if _, err := parseHeader(v); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -83,12 +83,45 @@ func TestValidateFlags(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "unsupported method",
+			name: "head request",
 			args: args{
 				rawurl:        "https://hoge.example.com",
 				method:        "HEAD",
 				customHeaders: []string{"Connection: keep-alive"},
 			},
+			wantErr: false,
+		},
+		{
+			name: "options request",
+			args: args{
+				rawurl: "https://hoge.example.com",
+				method: "OPTIONS",
+			},
+			wantErr: false,
+		},
+		{
+			name: "custom method request with data",
+			args: args{
+				rawurl: "https://hoge.example.com",
+				method: "PURGE",
+				data:   `{"hoge":"fuga"}`,
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty method",
+			args: args{
+				rawurl: "https://hoge.example.com",
+				method: "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid method",
+			args: args{
+				rawurl: "https://hoge.example.com",
+				method: "GET POST",
+			},
 			wantErr: true,
 		},
 		{
@@ -101,6 +134,16 @@ func TestValidateFlags(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "non json data with content type",
+			args: args{
+				rawurl:        "http://hoge.example.com",
+				method:        "POST",
+				data:          `a=b&c=d`,
+				customHeaders: []string{"Content-Type: application/x-www-form-urlencoded"},
+			},
+			wantErr: false,
+		},
 		{
 			name: "empty header text",
 			args: args{
@@ -111,13 +154,32 @@ func TestValidateFlags(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "header value with colons",
+			args: args{
+				rawurl:        "http://hoge.example.com",
+				method:        "POST",
+				data:          `{"hoge":"fuga"}`,
+				customHeaders: []string{"hoge:huga:hige", "Referer: https://example.com", "Authorization: Bearer a:b"},
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid header text",
 			args: args{
 				rawurl:        "http://hoge.example.com",
 				method:        "POST",
 				data:          `{"hoge":"fuga"}`,
-				customHeaders: []string{"hoge:huga:hige"},
+				customHeaders: []string{"hoge huga"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid header name",
+			args: args{
+				rawurl:        "http://hoge.example.com",
+				method:        "GET",
+				customHeaders: []string{"ho ge: huga"},
 			},
 			wantErr: true,
 		},
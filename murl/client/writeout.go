@@ -0,0 +1,116 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// curl の --write-out と同じ "%{name}" の形式の変数を展開した文字列を返すメソッド
+// "\n", "\r", "\t" はそれぞれ改行・復帰・タブに、"%%" は "%" に置き換える
+// レスポンスのボディを読み込んだ後に呼び出す必要がある
+func (e *Exchange) WriteOut(format string) (string, error) {
+	e.done()
+
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; {
+		case c == '%' && strings.HasPrefix(format[i:], "%%"):
+			b.WriteByte('%')
+			i++
+		case c == '%' && strings.HasPrefix(format[i:], "%{"):
+			end := strings.IndexByte(format[i:], '}')
+			if end == -1 {
+				b.WriteString(format[i:])
+				return b.String(), nil
+			}
+			name := format[i+2 : i+end]
+			v, err := e.variable(name)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i += end
+		case c == '\\' && i+1 < len(format):
+			switch format[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteString(format[i : i+2])
+			}
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+// --write-out の変数の値を返すメソッド
+func (e *Exchange) variable(name string) (string, error) {
+	res, t := e.Response, e.Timing
+	// リクエストを開始してからの経過秒数
+	since := func(at time.Time) string {
+		return fmt.Sprintf("%.6f", between(t.Start, at).Seconds())
+	}
+
+	switch name {
+	case "http_code", "response_code":
+		return fmt.Sprintf("%03d", res.StatusCode), nil
+	case "http_version":
+		// curl と同じく HTTP/2 以降はメジャーバージョンのみとする（"1.0", "1.1", "2", "3"）
+		if res.ProtoMajor >= 2 {
+			return strconv.Itoa(res.ProtoMajor), nil
+		}
+		return fmt.Sprintf("%d.%d", res.ProtoMajor, res.ProtoMinor), nil
+	case "method":
+		return e.Request.Method, nil
+	case "scheme":
+		return strings.ToUpper(res.Request.URL.Scheme), nil
+	case "url":
+		return e.Request.URL.String(), nil
+	case "url_effective":
+		return res.Request.URL.String(), nil
+	case "num_redirects":
+		return strconv.Itoa(len(e.Hops)), nil
+	case "redirect_url":
+		if res.StatusCode/100 == 3 {
+			if u, err := res.Location(); err == nil {
+				return u.String(), nil
+			}
+		}
+		return "", nil
+	case "content_type":
+		return res.Header.Get("Content-Type"), nil
+	case "size_download":
+		return strconv.FormatInt(e.size, 10), nil
+	case "remote_ip", "remote_port":
+		host, port, err := net.SplitHostPort(t.RemoteAddr)
+		if err != nil {
+			return "", nil
+		}
+		if name == "remote_ip" {
+			return host, nil
+		}
+		return port, nil
+	case "time_namelookup":
+		return since(t.DNSDone), nil
+	case "time_connect":
+		return since(t.ConnectDone), nil
+	case "time_appconnect":
+		return since(t.TLSDone), nil
+	case "time_pretransfer":
+		return since(t.GotConn), nil
+	case "time_starttransfer":
+		return since(t.FirstByte), nil
+	case "time_total":
+		return since(t.Done), nil
+	}
+	return "", fmt.Errorf("unknown --write-out variable '%s'", name)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchange_WriteOut(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/old", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c, err := NewHttpClient(ts.URL+"/old", http.MethodGet, "", nil, WithFollowRedirects(DefaultMaxRedirects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex, err := c.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	ex.ResponseText()
+
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Status", format: "%{http_code}\n", want: "200\n"},
+		{name: "Response", format: "%{content_type} %{size_download} %{http_version} %{num_redirects}", want: "application/json 15 1.1 1"},
+		{name: "URL", format: "%{method} %{url} -> %{url_effective} (%{scheme})", want: "GET " + ts.URL + "/old -> " + ts.URL + "/new (HTTP)"},
+		{name: "Escapes", format: `%%{http_code}\t%{http_code}\r\x`, want: "%{http_code}\t200\r\\x"},
+		{name: "Unterminated", format: "%{http_code", want: "%{http_code"},
+		{name: "Unknown variable", format: "%{unknown}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ex.WriteOut(tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExchange_WriteOut_Time(t *testing.T) {
+	start := time.Date(2023, 1, 4, 8, 26, 15, 0, time.UTC)
+	ex := &Exchange{
+		Request:  &http.Request{Method: http.MethodGet},
+		Response: &http.Response{StatusCode: http.StatusOK, Body: http.NoBody},
+		Timing: &Timing{
+			Start:       start,
+			DNSDone:     start.Add(1500 * time.Microsecond),
+			ConnectDone: start.Add(3 * time.Millisecond),
+			FirstByte:   start.Add(40 * time.Millisecond),
+			Done:        start.Add(42 * time.Millisecond),
+			RemoteAddr:  "127.0.0.1:8080",
+		},
+	}
+
+	got, err := ex.WriteOut("%{time_namelookup} %{time_connect} %{time_appconnect} %{time_starttransfer} %{time_total} %{remote_ip}:%{remote_port}")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.001500 0.003000 0.000000 0.040000 0.042000 127.0.0.1:8080", got)
+}
+
+func TestExchange_WriteOut_HTTPVersion(t *testing.T) {
+	tests := []struct {
+		proto        string
+		major, minor int
+		want         string
+	}{
+		{proto: "HTTP/1.0", major: 1, minor: 0, want: "1.0"},
+		{proto: "HTTP/1.1", major: 1, minor: 1, want: "1.1"},
+		{proto: "HTTP/2.0", major: 2, minor: 0, want: "2"},
+		{proto: "HTTP/3.0", major: 3, minor: 0, want: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto, func(t *testing.T) {
+			ex := &Exchange{
+				Request:  &http.Request{Method: http.MethodGet},
+				Response: &http.Response{StatusCode: http.StatusOK, Proto: tt.proto, ProtoMajor: tt.major, ProtoMinor: tt.minor, Body: http.NoBody},
+				Timing:   &Timing{},
+			}
+
+			got, err := ex.WriteOut("%{http_version}")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,8 +4,15 @@ Copyright © 2022 kurupeku <22340645+kurupeku@users.noreply.github.com>
 package cmd
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"murl/client"
 
@@ -15,6 +22,41 @@ import (
 var (
 	method, data  string
 	customHeaders = make([]string, 0)
+
+	dataURLEncode       []string
+	form                []string
+	dataBinary, dataRaw string
+
+	connectTimeout, maxTime float64
+	retry                   int
+	retryAllErrors          bool
+
+	location     bool
+	maxRedirects int
+
+	user, oauth2Bearer, netrcFile, awsSigV4 string
+	digest, netrc                           bool
+
+	tlsOptions     client.TLSOptions
+	tlsv12, tlsv13 bool
+
+	verbose, traceTime bool
+	writeOut           string
+
+	outputFormat, output string
+	include, silent      bool
+	continueAt           string
+	compressed           bool
+
+	cookies   []string
+	cookieJar string
+
+	proxyOptions client.ProxyOptions
+	noProxy      string
+
+	http11, http2, http2PriorKnowledge bool
+
+	bodyOptions client.BodyOptions
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -23,8 +65,10 @@ var rootCmd = &cobra.Command{
 	Short: "murl is http/https client command.",
 	Long: `murl is http/https client command.
 - Args: URL
-- Available HTTP Methods: GET, POST, PUT, DELETE, PATCH
-- Available Content-Type: application/json (only for POST, PUT, PATCH)`,
+- Available HTTP Methods: GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS, TRACE, CONNECT
+  and any custom method following the RFC token grammar (e.g. PURGE, PROPFIND)
+- Request body: sent with any method when one of the data flags is given
+  (Content-Type defaults to the type of the flag unless set with --header)`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
 			return fmt.Errorf("%s: You must set only URL", err.Error())
@@ -32,27 +76,198 @@ var rootCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceErrors, cmd.SilenceUsage = silent, silent
 		if err := client.ValidateFlags(args[0], method, data, customHeaders); err != nil {
 			return err
 		}
+		if (digest || awsSigV4 != "") && user == "" {
+			return errors.New("--digest and --aws-sigv4 require credentials with --user")
+		}
+		if !slices.Contains(client.Formats, outputFormat) {
+			return fmt.Errorf("invalid output format '%s' (available: %s)", outputFormat, strings.Join(client.Formats, ", "))
+		}
+		// -o のみを指定した場合は curl と同じくボディを保存する
+		if output != "" && !cmd.Flags().Changed("output-format") {
+			outputFormat = client.FormatRaw
+		}
+		var offset int64
+		if continueAt != "" {
+			if output == "" || outputFormat != client.FormatRaw {
+				return errors.New("--continue-at requires --output with the raw output format")
+			}
+			var err error
+			if offset, err = resumeOffset(continueAt, output); err != nil {
+				return err
+			}
+		}
+
+		opts := []client.Option{
+			client.WithDataURLEncode(dataURLEncode),
+			client.WithForm(form),
+			client.WithDataBinary(dataBinary),
+			client.WithDataRaw(dataRaw),
+			client.WithTimeout(seconds(connectTimeout), seconds(maxTime)),
+			client.WithRetry(retry, retryAllErrors, func(attempt int, wait time.Duration, reason string) {
+				if silent {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Warning: %s. Will retry in %s. %d retries left.\n", reason, wait.Round(time.Millisecond), retry-attempt+1)
+			}),
+		}
+		if location {
+			opts = append(opts, client.WithFollowRedirects(maxRedirects))
+		}
+		switch {
+		case awsSigV4 != "":
+			opts = append(opts, client.WithAWSSigV4(awsSigV4, user))
+		case digest:
+			opts = append(opts, client.WithDigestAuth(user))
+		default:
+			opts = append(opts, client.WithBasicAuth(user))
+		}
+		opts = append(opts, client.WithBearer(oauth2Bearer))
+		if netrc || netrcFile != "" {
+			opts = append(opts, client.WithNetrc(netrcFile))
+		}
+		switch {
+		case tlsv13:
+			tlsOptions.MinVersion = tls.VersionTLS13
+		case tlsv12:
+			tlsOptions.MinVersion = tls.VersionTLS12
+		}
+		opts = append(opts, client.WithTLS(tlsOptions))
+		if cmd.Flags().Changed("noproxy") {
+			proxyOptions.NoProxy = &noProxy
+		}
+		opts = append(opts, client.WithProxy(proxyOptions))
+		switch {
+		case http11:
+			opts = append(opts, client.WithHTTPVersion(client.HTTP11))
+		case http2:
+			opts = append(opts, client.WithHTTPVersion(client.HTTP2))
+		case http2PriorKnowledge:
+			opts = append(opts, client.WithHTTPVersion(client.HTTP2PriorKnowledge))
+		}
+		if verbose || traceTime {
+			opts = append(opts, client.WithVerbose(os.Stderr, traceTime))
+		}
+		bodyOptions.Color = output == "" && isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+		opts = append(opts, client.WithBodyOptions(bodyOptions))
+		opts = append(opts, client.WithResume(offset), client.WithCompressed(compressed))
+		opts = append(opts, client.WithCookies(cookies), client.WithCookieJar(cookieJar))
+		if output != "" && !silent {
+			opts = append(opts, client.WithProgress(os.Stderr))
+		}
+
+		writeOutFormat, err := readWriteOut(writeOut)
+		if err != nil {
+			return err
+		}
 
-		c, err := client.NewHttpClient(args[0], method, data, customHeaders)
+		c, err := client.NewHttpClient(args[0], method, data, customHeaders, opts...)
 		if err != nil {
 			return err
 		}
 
-		req, res, err := c.Execute()
+		ex, err := c.Do()
+		if errors.Is(err, client.ErrAlreadyComplete) {
+			if !silent {
+				fmt.Fprintf(os.Stderr, "%s is already completely downloaded.\n", output)
+			}
+			return nil
+		}
 		if err != nil {
 			return err
 		}
+		defer ex.Close()
 
-		fmt.Println(req)
-		fmt.Println(res)
+		if err := writeOutput(ex); err != nil {
+			return err
+		}
+		if err := c.SaveCookieJar(); err != nil {
+			return err
+		}
 
+		if writeOutFormat != "" {
+			out, err := ex.WriteOut(writeOutFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+		}
 		return nil
 	},
 }
 
+// レスポンスを --output のファイル（空の場合は標準出力）へ書き込む関数
+// ダウンロードを再開した場合はファイルに追記する。ファイルを閉じる際のエラーも返す
+func writeOutput(ex *client.Exchange) (err error) {
+	if output == "" {
+		return ex.Write(os.Stdout, outputFormat, include)
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if ex.Resumed {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(output, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return ex.Write(f, outputFormat, include)
+}
+
+// --write-out のテンプレートを返す関数
+// "@path" の場合はファイルから、"@-" の場合は標準入力から読み込む
+func readWriteOut(s string) (string, error) {
+	path, ok := strings.CutPrefix(s, "@")
+	if !ok {
+		return s, nil
+	}
+	if path == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		return string(b), err
+	}
+	b, err := os.ReadFile(path)
+	return string(b), err
+}
+
+// --continue-at の値からダウンロードを再開するバイト数を返す関数
+// "-" の場合は保存先のファイルのサイズ（存在しない場合は 0）とする
+func resumeOffset(s, path string) (int64, error) {
+	if s != "-" {
+		offset, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("invalid --continue-at '%s': must be '-' or a number of bytes", s)
+		}
+		return offset, nil
+	}
+	fi, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+// 秒数を time.Duration に変換する関数
+func seconds(sec float64) time.Duration {
+	return time.Duration(sec * float64(time.Second))
+}
+
+// f が端末かを返す関数
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -62,6 +277,57 @@ func Execute() {
 
 func init() {
 	rootCmd.Flags().StringVarP(&method, "request", "X", "GET", "HTTP method")
-	rootCmd.Flags().StringVarP(&data, "data", "d", "", "HTTP Post, Put, Patch Data")
+	rootCmd.Flags().StringVarP(&data, "data", "d", "", "HTTP request body in JSON (sent with any method)")
+	rootCmd.Flags().StringArrayVar(&dataURLEncode, "data-urlencode", []string{}, "HTTP request body as URL-encoded form data ('name=content' or 'name@file')")
+	rootCmd.Flags().StringArrayVarP(&form, "form", "F", []string{}, "Multipart form field ('name=value' or 'name=@path')")
+	rootCmd.Flags().StringVar(&dataBinary, "data-binary", "", "HTTP request body sent as is ('@path' reads the file)")
+	rootCmd.Flags().StringVar(&dataRaw, "data-raw", "", "HTTP request body sent as is without interpreting '@'")
+	rootCmd.MarkFlagsMutuallyExclusive("data", "data-urlencode", "form", "data-binary", "data-raw")
 	rootCmd.Flags().StringArrayVarP(&customHeaders, "header", "H", []string{}, "Pass custom header(s) to server")
+	rootCmd.Flags().Float64Var(&connectTimeout, "connect-timeout", 0, "Maximum time in seconds allowed for connection (0 means no limit)")
+	rootCmd.Flags().Float64VarP(&maxTime, "max-time", "m", 0, "Maximum time in seconds allowed for the whole operation including retries (0 means no limit)")
+	rootCmd.Flags().IntVar(&retry, "retry", 0, "Retry request up to N times on connection errors, 429 and 5xx responses")
+	rootCmd.Flags().BoolVar(&retryAllErrors, "retry-all-errors", false, "Retry on all errors and 4xx responses (use with --retry)")
+	rootCmd.Flags().BoolVarP(&location, "location", "L", false, "Follow redirects")
+	rootCmd.Flags().IntVar(&maxRedirects, "max-redirs", client.DefaultMaxRedirects, "Maximum number of redirects to follow with --location (-1 means no limit)")
+	rootCmd.Flags().StringVarP(&user, "user", "u", "", "Server user and password ('user:password') for Basic authentication")
+	rootCmd.Flags().StringVar(&oauth2Bearer, "oauth2-bearer", "", "OAuth 2.0 Bearer token")
+	rootCmd.Flags().BoolVar(&digest, "digest", false, "Use Digest authentication with the credentials of --user")
+	rootCmd.Flags().BoolVar(&netrc, "netrc", false, "Read credentials for the host from ~/.netrc")
+	rootCmd.Flags().StringVar(&netrcFile, "netrc-file", "", "Read credentials for the host from the given netrc file")
+	rootCmd.Flags().StringVar(&awsSigV4, "aws-sigv4", "", "Sign the request with AWS Signature Version 4 ('aws:amz:REGION:SERVICE') using --user as 'access_key:secret_key'")
+	rootCmd.MarkFlagsMutuallyExclusive("user", "oauth2-bearer")
+	rootCmd.MarkFlagsMutuallyExclusive("digest", "aws-sigv4")
+	rootCmd.MarkFlagsMutuallyExclusive("netrc", "netrc-file")
+	rootCmd.Flags().StringArrayVarP(&cookies, "cookie", "b", []string{}, "Send cookies ('NAME=VALUE; NAME2=VALUE2') or read them from a Netscape-format or JSON cookie file")
+	rootCmd.Flags().StringVarP(&cookieJar, "cookie-jar", "c", "", "Save all cookies to FILE after the request (JSON if FILE ends with .json, otherwise Netscape format)")
+	rootCmd.Flags().StringVar(&tlsOptions.CACert, "cacert", "", "CA certificate file (PEM) to verify the server with instead of the system CAs")
+	rootCmd.Flags().StringVar(&tlsOptions.CAPath, "capath", "", "Directory of CA certificate files (PEM) to verify the server with instead of the system CAs")
+	rootCmd.Flags().StringVarP(&tlsOptions.Cert, "cert", "E", "", "Client certificate file (PEM) for mutual TLS")
+	rootCmd.Flags().StringVar(&tlsOptions.Key, "key", "", "Private key file (PEM) of the client certificate (defaults to --cert)")
+	rootCmd.Flags().BoolVarP(&tlsOptions.Insecure, "insecure", "k", false, "Skip verification of the server certificate")
+	rootCmd.Flags().BoolVar(&tlsv12, "tlsv1.2", false, "Use TLS 1.2 or later")
+	rootCmd.Flags().BoolVar(&tlsv13, "tlsv1.3", false, "Use TLS 1.3 or later")
+	rootCmd.Flags().StringVar(&tlsOptions.PinnedPubKey, "pinnedpubkey", "", "Public key hash(es) of the server to pin ('sha256//BASE64', separated by ';')")
+	rootCmd.Flags().BoolVar(&tlsOptions.Show, "show-tls", false, "Show the negotiated TLS version, cipher and certificate chain in the response")
+	rootCmd.MarkFlagsMutuallyExclusive("tlsv1.2", "tlsv1.3")
+	rootCmd.Flags().StringVarP(&proxyOptions.Proxy, "proxy", "x", "", "Use the proxy ('[scheme://][user:password@]host[:port]', scheme is http, https, socks5 or socks5h) instead of http_proxy and https_proxy")
+	rootCmd.Flags().StringVarP(&proxyOptions.User, "proxy-user", "U", "", "User and password ('user:password') for the proxy")
+	rootCmd.Flags().StringVar(&noProxy, "noproxy", "", "Comma-separated hosts, domains, IP addresses and CIDRs to connect to without the proxy ('*' for all) instead of no_proxy")
+	rootCmd.Flags().BoolVar(&http11, "http1.1", false, "Use HTTP/1.1 only")
+	rootCmd.Flags().BoolVar(&http2, "http2", false, "Use HTTP/2 when negotiated with ALPN over TLS (HTTP/1.1 over cleartext)")
+	rootCmd.Flags().BoolVar(&http2PriorKnowledge, "http2-prior-knowledge", false, "Use HTTP/2 only, also over cleartext (h2c) without upgrading from HTTP/1.1")
+	rootCmd.MarkFlagsMutuallyExclusive("http1.1", "http2", "http2-prior-knowledge")
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show the connection progress and header lines as sent and received on stderr, and timings in the response")
+	rootCmd.Flags().BoolVar(&traceTime, "trace-time", false, "Prefix each verbose line with the time (implies --verbose)")
+	rootCmd.Flags().StringVar(&outputFormat, "output-format", client.FormatText, "Output format ("+strings.Join(client.Formats, ", ")+")")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "Write the output to FILE instead of stdout (the response body only unless --output-format is given, with a progress bar on stderr)")
+	rootCmd.Flags().StringVarP(&continueAt, "continue-at", "C", "", "Resume the download to --output from the given byte offset ('-' uses the size of the existing file)")
+	rootCmd.Flags().BoolVar(&compressed, "compressed", false, "Request a compressed response (gzip, deflate, br, zstd) and decompress it")
+	rootCmd.Flags().BoolVarP(&include, "include", "i", false, "Include the status line and response headers before the body in the raw output format")
+	rootCmd.Flags().BoolVarP(&silent, "silent", "s", false, "Do not show the progress bar, retry warnings and error messages")
+	rootCmd.Flags().BoolVar(&bodyOptions.Pretty, "pretty", false, "Indent JSON, XML and HTML response bodies (colored on a terminal)")
+	rootCmd.Flags().StringVar(&bodyOptions.JQ, "jq", "", "Extract values from a JSON response body with a jq-style path (e.g. '.items[0].name', '.items[].id')")
+	rootCmd.Flags().BoolVar(&bodyOptions.Hexdump, "hexdump", false, "Show binary response bodies as a hexdump instead of a size and type summary")
+	rootCmd.Flags().StringVarP(&writeOut, "write-out", "w", "", "Output curl-style variables after completion (e.g. '%{http_code} %{time_total}\\n', '@file' reads the template)")
 }
@@ -3,9 +3,12 @@ module murl
 go 1.24.0
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/jarcoal/httpmock v1.2.0
+	github.com/klauspost/compress v1.18.0
 	github.com/spf13/cobra v1.6.1
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/net v0.44.0
 )
 
 require (